@@ -0,0 +1,161 @@
+// Package cache stores the Go module version numbers collected for a
+// plugin's package so that commands like asdf list-all and asdf latest
+// don't have to collect them again on every invocation.
+//
+// Cached Collections are stored in the plugin's directory (next to the
+// plugin's manifest) and are used until they're older than the TTL set
+// by AGI_CACHE_TTL.  Once expired, the versions are collected again,
+// but the HTTP responses that carry an ETag or Last-Modified header are
+// also cached, so the servers are asked to revalidate them with a
+// conditional request instead of sending the whole response again.
+// Responses that haven't been used for twice the TTL (and at least a
+// day) are pruned, and bodies larger than MaxResponseSize aren't cached.
+//
+// asdf doesn't pass any arguments to bin/list-all, so the cached
+// Collections are bypassed by setting AGI_CACHE_REFRESH instead of with
+// a command-line flag.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/lmittmann/tint"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/lockedfile"
+)
+
+const (
+	// VersionsFilename is the name of the file in the plugin's directory
+	// that stores the cached Collections.
+	VersionsFilename = "versions.json"
+
+	// ResponsesDirname is the name of the directory in the plugin's
+	// directory that stores the cached HTTP responses.
+	ResponsesDirname = "responses"
+)
+
+// Cache stores the Collections and HTTP responses for a single plugin.
+type Cache struct {
+	cfg     *config.Config
+	dir     string
+	ttl     time.Duration
+	refresh bool
+}
+
+// New creates a Cache that stores its files in the provided plugin
+// directory.
+//
+// When AGI_CACHE_REFRESH is set, cached Collections are ignored, but
+// freshly collected Collections are still stored.
+func New(cfg *config.Config, dir string) *Cache {
+	return &Cache{
+		cfg:     cfg,
+		dir:     dir,
+		ttl:     cfg.Env().CacheTTL(),
+		refresh: cfg.Env().CacheRefresh(),
+	}
+}
+
+// Collector wraps the provided Collector so that its Collections are
+// stored in the Cache and reused until they expire.
+//
+// Failing to read or write the Cache is logged but doesn't fail the
+// collection.
+func (c *Cache) Collector(collect gover.Collector) gover.Collector {
+	return func(ctx context.Context, cfg *config.Config, pkg string) (*gover.Collection, error) {
+		if !c.refresh {
+			col, collected, err := c.load(pkg)
+
+			switch {
+			case err != nil:
+				cfg.Log().Warn("Ignoring unreadable version cache", slog.String("package", pkg), tint.Err(err))
+			case col != nil && time.Since(collected) < c.ttl:
+				cfg.Log().Debug(
+					"Using cached versions",
+					slog.String("package", pkg),
+					slog.Time("collected", collected),
+				)
+
+				return col, nil
+			}
+		}
+
+		col, err := collect(context.WithValue(ctx, cacheKey{}, c), cfg, pkg)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.store(pkg, col); err != nil {
+			cfg.Log().Warn("Failed to cache versions", slog.String("package", pkg), tint.Err(err))
+		}
+
+		if err := c.prune(); err != nil {
+			cfg.Log().Warn("Failed to prune cached responses", tint.Err(err))
+		}
+
+		return col, nil
+	}
+}
+
+type cacheKey struct{}
+
+type entry struct {
+	Collected time.Time         `json:"collected"`
+	Versions  *gover.Collection `json:"versions"`
+}
+
+func (c *Cache) versionsPath() string {
+	return filepath.Join(c.dir, VersionsFilename)
+}
+
+// load returns the cached Collection for the package and the time when
+// it was collected or a nil Collection if there is no cached entry.
+func (c *Cache) load(pkg string) (*gover.Collection, time.Time, error) {
+	data, err := lockedfile.Read(c.versionsPath())
+	if err != nil {
+		return nil, time.Time{}, ignoreNotExist(err)
+	}
+
+	var entries map[string]entry
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	ent, ok := entries[pkg]
+	if !ok || ent.Versions == nil {
+		return nil, time.Time{}, nil
+	}
+
+	return ent.Versions, ent.Collected, nil
+}
+
+// store adds the Collection for the package to the cached entries.  An
+// unreadable file is replaced rather than preventing new entries from
+// being cached.
+func (c *Cache) store(pkg string, col *gover.Collection) error {
+	return lockedfile.Update(c.versionsPath(), 0o644, func(data []byte) ([]byte, error) {
+		entries := map[string]entry{}
+
+		if data != nil {
+			if err := json.Unmarshal(data, &entries); err != nil {
+				c.cfg.Log().Warn("Replacing unreadable version cache", tint.Err(err))
+
+				entries = map[string]entry{}
+			}
+		}
+
+		entries[pkg] = entry{
+			Collected: time.Now(),
+			Versions:  col,
+		}
+
+		return json.MarshalIndent(entries, "", "  ")
+	})
+}
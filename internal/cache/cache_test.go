@@ -0,0 +1,179 @@
+package cache_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/cache"
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/gover/govertest"
+)
+
+const pkg = "example.com/tool/cmd/tool"
+
+var errCollect = errors.New("collection failed")
+
+func TestCache_Collector(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		environ  []string
+		expCalls int32
+	}{
+		"cached": {
+			environ:  []string{},
+			expCalls: 1,
+		},
+		"expired": {
+			environ:  []string{"AGI_CACHE_TTL=0s"},
+			expCalls: 2,
+		},
+		"refresh environment variable": {
+			environ:  []string{"AGI_CACHE_REFRESH=true"},
+			expCalls: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, _ := configtest.NewConfig(t, test.environ, []string{})
+			dir := t.TempDir()
+
+			collect, calls := counting(t, "v1.0.0 v1.1.0")
+
+			for range 2 {
+				col, err := cache.New(cfg, dir).Collector(collect)(context.Background(), cfg, pkg)
+				require.NoError(t, err)
+				assert.Equal(t, "v1.0.0 v1.1.0", col.String())
+			}
+
+			assert.Equal(t, test.expCalls, calls.Load())
+		})
+	}
+}
+
+func TestCache_Collector_Metadata(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+	dir := t.TempDir()
+
+	exp := govertest.NewCollection(t, "v1.0.0 v1.0.1").WithModule("example.com/tool").WithSource("goproxy").
+		WithRetractions(gover.Retraction{Low: govertest.NewVersion(t, "v1.0.1"), High: govertest.NewVersion(t, "v1.0.1"), Rationale: "Broken."})
+
+	collect := func(context.Context, *config.Config, string) (*gover.Collection, error) {
+		return exp, nil
+	}
+
+	_, err := cache.New(cfg, dir).Collector(collect)(context.Background(), cfg, pkg)
+	require.NoError(t, err)
+
+	act, err := cache.New(cfg, dir).Collector(failing)(context.Background(), cfg, pkg)
+	require.NoError(t, err)
+	assert.Equal(t, exp.String(), act.String())
+	assert.Equal(t, exp.Retractions(), act.Retractions())
+
+	for _, ver := range act.All() {
+		assert.Equal(t, "example.com/tool", act.Module(ver))
+		assert.Equal(t, "goproxy", act.Source(ver))
+	}
+}
+
+func TestCache_Collector_Error(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+	dir := t.TempDir()
+
+	col, err := cache.New(cfg, dir).Collector(failing)(context.Background(), cfg, pkg)
+	require.ErrorIs(t, err, errCollect)
+	assert.Nil(t, col)
+
+	_, err = os.Stat(filepath.Join(dir, cache.VersionsFilename))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCache_Collector_Corrupt(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, cache.VersionsFilename), []byte("{"), 0o644))
+
+	collect, calls := counting(t, "v1.0.0")
+
+	for range 2 {
+		col, err := cache.New(cfg, dir).Collector(collect)(context.Background(), cfg, pkg)
+		require.NoError(t, err)
+		assert.Equal(t, "v1.0.0", col.String())
+	}
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestCache_Collector_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	const processes = 10
+
+	cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+
+	for i := range processes {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			collect, _ := counting(t, "v1.0."+strconv.Itoa(i))
+
+			_, err := cache.New(cfg, dir).Collector(collect)(context.Background(), cfg, pkg+strconv.Itoa(i))
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	data, err := os.ReadFile(filepath.Join(dir, cache.VersionsFilename))
+	require.NoError(t, err)
+
+	var entries map[string]json.RawMessage
+
+	require.NoError(t, json.Unmarshal(data, &entries))
+	assert.Len(t, entries, processes)
+}
+
+func counting(t *testing.T, vers string) (gover.Collector, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+
+	col := govertest.NewCollection(t, vers)
+
+	return func(context.Context, *config.Config, string) (*gover.Collection, error) {
+		calls.Add(1)
+
+		return col, nil
+	}, &calls
+}
+
+func failing(context.Context, *config.Config, string) (*gover.Collection, error) {
+	return nil, errCollect
+}
@@ -0,0 +1,236 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lmittmann/tint"
+
+	"github.com/selesy/asdf-go-install/internal/lockedfile"
+)
+
+const (
+	// MaxResponseSize is the largest response body that's cached.
+	// Larger responses are passed through without being stored.
+	MaxResponseSize = 4 << 20
+
+	// minResponseRetention is the shortest time that an unused response
+	// is kept (see Cache.prune.)
+	minResponseRetention = 24 * time.Hour
+)
+
+// Transport returns an http.RoundTripper that caches the responses to
+// GET requests which carry an ETag or Last-Modified header and
+// revalidates them with If-None-Match and If-Modified-Since requests.
+//
+// Responses are only cached while collecting versions through a
+// Cache's Collector (which stores the Cache in the context.)  Otherwise,
+// the next http.RoundTripper is returned unchanged.
+func Transport(ctx context.Context, next http.RoundTripper) http.RoundTripper {
+	c, ok := ctx.Value(cacheKey{}).(*Cache)
+	if !ok {
+		return next
+	}
+
+	return &transport{
+		cache: c,
+		next:  next,
+	}
+}
+
+var _ http.RoundTripper = (*transport)(nil)
+
+type transport struct {
+	cache *Cache
+	next  http.RoundTripper
+}
+
+type response struct {
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	log := t.cache.cfg.Log().With(slog.String("url", req.URL.String()))
+	path := t.cache.responsePath(req)
+
+	stored, err := readResponse(path)
+	if err != nil {
+		log.Warn("Ignoring unreadable cached response", tint.Err(err))
+	}
+
+	if stored != nil {
+		req = req.Clone(req.Context())
+
+		if etag := stored.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		if lastModified := stored.Header.Get("Last-Modified"); lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && stored != nil:
+		resp.Body.Close()
+
+		log.Debug("Revalidated cached response")
+
+		// The modification time records when the response was last
+		// used so that it isn't pruned.
+		now := time.Now()
+		if err := os.Chtimes(path, now, now); err != nil {
+			log.Warn("Failed to touch cached response", tint.Err(err))
+		}
+
+		return stored.response(req), nil
+	case resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""):
+		body, err := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSize+1))
+		if err != nil {
+			resp.Body.Close()
+
+			return nil, err
+		}
+
+		if len(body) > MaxResponseSize {
+			log.Debug("Not caching oversized response")
+
+			resp.Body = &readCloser{
+				Reader: io.MultiReader(bytes.NewReader(body), resp.Body),
+				Closer: resp.Body,
+			}
+
+			return resp, nil
+		}
+
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		if err := writeResponse(path, &response{URL: req.URL.String(), Header: resp.Header, Body: body}); err != nil {
+			log.Warn("Failed to cache response", tint.Err(err))
+		}
+	}
+
+	return resp, nil
+}
+
+// response recreates the original response from the cached copy.
+func (r *response) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// readCloser returns the part of a response body that's already been
+// read followed by the remainder of the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (c *Cache) responsePath(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String()))
+
+	return filepath.Join(c.dir, ResponsesDirname, hex.EncodeToString(sum[:])+".json")
+}
+
+// prune removes the cached responses that haven't been stored or
+// revalidated for twice the TTL (but at least minResponseRetention.)
+// Responses are only revalidated once the Collection they were used
+// for expires, so a response that's still in use is always touched
+// within that time.
+func (c *Cache) prune() error {
+	dir := filepath.Join(c.dir, ResponsesDirname)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ignoreNotExist(err)
+	}
+
+	retention := max(2*c.ttl, minResponseRetention)
+
+	stale := func(info fs.FileInfo) bool {
+		return time.Since(info.ModTime()) > retention
+	}
+
+	var errs []error
+
+	for _, ent := range entries {
+		if ent.IsDir() || !strings.HasSuffix(ent.Name(), ".json") {
+			continue
+		}
+
+		info, err := ent.Info()
+		if err != nil || !stale(info) {
+			continue
+		}
+
+		errs = append(errs, lockedfile.Remove(filepath.Join(dir, ent.Name()), stale))
+	}
+
+	return errors.Join(errs...)
+}
+
+func readResponse(path string) (*response, error) {
+	data, err := lockedfile.Read(path)
+	if err != nil {
+		return nil, ignoreNotExist(err)
+	}
+
+	var resp response
+
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func writeResponse(path string, resp *response) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	return lockedfile.Write(path, data, 0o644)
+}
+
+func ignoreNotExist(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
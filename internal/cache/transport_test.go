@@ -0,0 +1,234 @@
+package cache_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/cache"
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/gover"
+)
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		header    string
+		value     string
+		condition string
+	}{
+		"ETag": {
+			header:    "ETag",
+			value:     `"v1"`,
+			condition: "If-None-Match",
+		},
+		"Last-Modified": {
+			header:    "Last-Modified",
+			value:     "Mon, 02 Jan 2006 15:04:05 GMT",
+			condition: "If-Modified-Since",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var full, revalidated atomic.Int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(test.condition) == test.value {
+					revalidated.Add(1)
+					w.WriteHeader(http.StatusNotModified)
+
+					return
+				}
+
+				full.Add(1)
+				w.Header().Set(test.header, test.value)
+				w.Header().Set("Content-Type", "text/plain")
+				_, _ = io.WriteString(w, "v1.0.0\nv1.1.0\n")
+			}))
+			t.Cleanup(srv.Close)
+
+			cfg, _, _ := configtest.NewConfig(t, []string{"AGI_CACHE_TTL=0s"}, []string{})
+			collect := cache.New(cfg, t.TempDir()).Collector(fetching(srv.URL))
+
+			for range 3 {
+				col, err := collect(context.Background(), cfg, pkg)
+				require.NoError(t, err)
+				assert.Equal(t, "v1.0.0 v1.1.0", col.String())
+			}
+
+			assert.Equal(t, int32(1), full.Load())
+			assert.Equal(t, int32(2), revalidated.Load())
+		})
+	}
+}
+
+func TestTransport_WithoutValidators(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Empty(t, r.Header.Get("If-None-Match"))
+		assert.Empty(t, r.Header.Get("If-Modified-Since"))
+		_, _ = io.WriteString(w, "v1.0.0\n")
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"AGI_CACHE_TTL=0s"}, []string{})
+	collect := cache.New(cfg, t.TempDir()).Collector(fetching(srv.URL))
+
+	for range 2 {
+		_, err := collect(context.Background(), cfg, pkg)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestTransport_Oversized(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	body := strings.Repeat("x", cache.MaxResponseSize+1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Empty(t, r.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"AGI_CACHE_TTL=0s"}, []string{})
+	dir := t.TempDir()
+
+	collect := cache.New(cfg, dir).Collector(func(ctx context.Context, _ *config.Config, _ string) (*gover.Collection, error) {
+		client := &http.Client{Transport: cache.Transport(ctx, http.DefaultTransport)}
+
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		assert.Len(t, data, len(body), "the whole body should be returned")
+
+		return gover.NewCollection(), nil
+	})
+
+	for range 2 {
+		_, err := collect(context.Background(), cfg, pkg)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(2), requests.Load())
+
+	_, err := os.Stat(filepath.Join(dir, cache.ResponsesDirname))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestTransport_Prune(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, "v1.0.0\n")
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"AGI_CACHE_TTL=0s"}, []string{})
+	dir := t.TempDir()
+	responses := filepath.Join(dir, cache.ResponsesDirname)
+
+	stale := filepath.Join(responses, "stale.json")
+	require.NoError(t, os.MkdirAll(responses, 0o755))
+	require.NoError(t, os.WriteFile(stale, []byte("{}"), 0o644))
+	require.NoError(t, os.WriteFile(stale+".lock", nil, 0o644))
+
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	_, err := cache.New(cfg, dir).Collector(fetching(srv.URL))(context.Background(), cfg, pkg)
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(responses)
+	require.NoError(t, err)
+
+	var names []string
+
+	for _, ent := range entries {
+		names = append(names, ent.Name())
+	}
+
+	require.Len(t, names, 3, "only the fresh response and the lock files should remain")
+	assert.NotContains(t, names, "stale.json")
+	assert.Contains(t, names, "stale.json.lock", "lock files should never be removed")
+}
+
+func TestTransport_WithoutCache(t *testing.T) {
+	t.Parallel()
+
+	assert.Same(t, http.DefaultTransport, cache.Transport(context.Background(), http.DefaultTransport))
+}
+
+// fetching returns a Collector that reads newline-delimited versions
+// from the server through the cache's Transport.
+func fetching(u string) gover.Collector {
+	return func(ctx context.Context, _ *config.Config, _ string) (*gover.Collection, error) {
+		client := &http.Client{Transport: cache.Transport(ctx, http.DefaultTransport)}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		var vers []*semver.Version
+
+		for _, str := range strings.Fields(string(data)) {
+			ver, err := gover.NewVersion(str)
+			if err != nil {
+				return nil, err
+			}
+
+			vers = append(vers, ver)
+		}
+
+		return gover.NewCollection(vers...), nil
+	}
+}
@@ -9,8 +9,10 @@ import (
 	"fmt"
 	"log/slog"
 	"net/url"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/caarlos0/env/v10"
@@ -23,6 +25,7 @@ import (
 type Env struct {
 	agiVar  agiVar
 	asdfVar asdfVar
+	goVar   goVar
 }
 
 // New parses the (relevant) environment variables available from the OS
@@ -51,9 +54,8 @@ func New(log *slog.Logger, environ []string) (*Env, error) {
 		Environment:           env.ToMap(environ),
 		UseFieldNameByDefault: true,
 		FuncMap: map[reflect.Type]env.ParserFunc{
-			reflect.TypeOf((*semver.Version)(nil)): parseSemVer,
-			reflect.TypeOf((*url.URL)(nil)):        parseURL,
-			reflect.TypeOf((*hash.Hash)(nil)):      parseGitHash, // TODO: not current used
+			reflect.TypeOf((*url.URL)(nil)):   parseURL,
+			reflect.TypeOf((*hash.Hash)(nil)): parseGitHash, // TODO: not current used
 		},
 	}); err != nil {
 		return nil, err
@@ -71,7 +73,21 @@ func New(log *slog.Logger, environ []string) (*Env, error) {
 		Prefix:                "AGI_",
 		Environment:           env.ToMap(environ),
 		UseFieldNameByDefault: true,
-		FuncMap:               map[reflect.Type]env.ParserFunc{},
+		FuncMap: map[reflect.Type]env.ParserFunc{
+			reflect.TypeOf((*url.URL)(nil)): parseURL,
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	if u := agiVar.PkgsiteURL; u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPkgsiteURL, u)
+	}
+
+	var goVar goVar
+
+	if err := env.ParseWithOptions(&goVar, env.Options{
+		Environment: env.ToMap(environ),
 	}); err != nil {
 		return nil, err
 	}
@@ -79,6 +95,7 @@ func New(log *slog.Logger, environ []string) (*Env, error) {
 	e := &Env{
 		agiVar:  agiVar,
 		asdfVar: asdfVar,
+		goVar:   goVar,
 	}
 
 	resolvedEnvironment := func(attr slog.Attr) {
@@ -104,6 +121,18 @@ func New(log *slog.Logger, environ []string) (*Env, error) {
 	return e, nil
 }
 
+// CacheRefresh returns true if cached versions should be ignored and
+// collected again.
+func (e *Env) CacheRefresh() bool {
+	return e.agiVar.CacheRefresh
+}
+
+// CacheTTL returns how long collected versions are cached before they
+// must be revalidated.
+func (e *Env) CacheTTL() time.Duration {
+	return e.agiVar.CacheTTL
+}
+
 // CmdFile resolves to the full path of the file being executed.
 func (e *Env) CmdFile() string {
 	return e.asdfVar.CmdFile
@@ -144,6 +173,76 @@ func (e *Env) DownloadPath() string {
 	return e.asdfVar.DownloadPath
 }
 
+// GoModCache returns the directory where the go command stores
+// downloaded modules using the same defaults as the go command: the
+// GOMODCACHE environment variable, then pkg/mod in the first GOPATH
+// entry and finally $HOME/go/pkg/mod.  An empty string is returned if
+// none of these are set.
+func (e *Env) GoModCache() string {
+	if e.goVar.GoModCache != "" {
+		return e.goVar.GoModCache
+	}
+
+	if gopath, _, _ := strings.Cut(e.goVar.GoPath, string(filepath.ListSeparator)); gopath != "" {
+		return filepath.Join(gopath, "pkg", "mod")
+	}
+
+	if e.goVar.Home != "" {
+		return filepath.Join(e.goVar.Home, "go", "pkg", "mod")
+	}
+
+	return ""
+}
+
+// GoNoProxy returns the comma-separated list of module path prefix
+// patterns that should always be fetched directly from their version
+// control repositories.
+//
+// As with the go command, GOPRIVATE is used when GONOPROXY is unset.
+func (e *Env) GoNoProxy() string {
+	if e.goVar.GoNoProxy != "" {
+		return e.goVar.GoNoProxy
+	}
+
+	return e.goVar.GoPrivate
+}
+
+// GoPath returns the go command's GOPATH or an empty string if it isn't
+// set.
+func (e *Env) GoPath() string {
+	return e.goVar.GoPath
+}
+
+// GoProxy returns the list of Go module proxies that should be used
+// when collecting module versions.
+func (e *Env) GoProxy() string {
+	return e.goVar.GoProxy
+}
+
+// GoNoSumCheck returns true if downloaded modules shouldn't be verified
+// against the checksum database at all.
+func (e *Env) GoNoSumCheck() bool {
+	return e.goVar.GoNoSumCheck
+}
+
+// GoNoSumDB returns the comma-separated list of module path prefix
+// patterns that shouldn't be verified against the checksum database.
+//
+// As with the go command, GOPRIVATE is used when GONOSUMDB is unset.
+func (e *Env) GoNoSumDB() string {
+	if e.goVar.GoNoSumDB != "" {
+		return e.goVar.GoNoSumDB
+	}
+
+	return e.goVar.GoPrivate
+}
+
+// GoSumDB returns the name (and optionally the public key and URL) of
+// the checksum database used to verify downloaded modules or "off".
+func (e *Env) GoSumDB() string {
+	return e.goVar.GoSumDB
+}
+
 // InstallType returns either InstallTypeVersion or InstallTypeRef.
 func (e *Env) InstallType() InstallType {
 	return e.asdfVar.InstallType
@@ -155,12 +254,34 @@ func (e *Env) InstallPath() string {
 	return e.asdfVar.InstallPath
 }
 
-// InstallVersion returns the full version number or Git reference
-// depending on the value returned by InstallType().
-func (e *Env) InstallVersion() *semver.Version {
+// InstallQuery returns the unparsed version query, full version number
+// or Git reference depending on the value returned by InstallType().
+func (e *Env) InstallQuery() string {
 	return e.asdfVar.InstallVersion
 }
 
+// InstallVersion returns the full version number requested by asdf or
+// nil if the requested version is a version query (e.g. v1.2 or latest)
+// or a Git reference.
+func (e *Env) InstallVersion() *semver.Version {
+	if _, err := semver.StrictNewVersion(strings.TrimPrefix(e.asdfVar.InstallVersion, "v")); err != nil {
+		return nil
+	}
+
+	ver, err := semver.NewVersion(e.asdfVar.InstallVersion)
+	if err != nil {
+		return nil
+	}
+
+	return ver
+}
+
+// ListRetracted returns how retracted versions should be presented by
+// bin/list-all (see goinstall.List.)
+func (e *Env) ListRetracted() ListRetracted {
+	return e.agiVar.ListRetracted
+}
+
 // LogFormat returns the format of the logger's output.
 func (e *Env) LogFormat() LogFormat {
 	return e.agiVar.LogFormat
@@ -182,6 +303,18 @@ func (e *Env) LogSource() bool {
 	return e.agiVar.LogSource
 }
 
+// Offline returns true if versions should only be collected from, and
+// installed using, the local Go module cache.
+func (e *Env) Offline() bool {
+	return e.agiVar.Offline
+}
+
+// PkgsiteURL returns the base URL of the pkg.go.dev web-site or of a
+// self-hosted pkgsite mirror.
+func (e *Env) PkgsiteURL() *url.URL {
+	return e.agiVar.PkgsiteURL
+}
+
 // PluginPath returns the path where the plugin was installed.
 func (e *Env) PluginPath() string {
 	return e.asdfVar.PluginPath
@@ -204,6 +337,13 @@ func (e *Env) PluginSourceURL() *url.URL {
 	return e.asdfVar.PluginSourceURL
 }
 
+// RequestTimeout returns the maximum duration of each network request
+// made while collecting tool information.  A zero duration means that
+// requests don't time out.
+func (e *Env) RequestTimeout() time.Duration {
+	return e.agiVar.RequestTimeout
+}
+
 func parseGitHash(s string) (any, error) {
 	decoded, err := hex.DecodeString(s)
 	if err != nil {
@@ -217,19 +357,59 @@ func parseGitHash(s string) (any, error) {
 	return nil, nil
 }
 
-func parseSemVer(s string) (any, error) {
-	return semver.StrictNewVersion(s)
-}
-
 func parseURL(s string) (any, error) {
 	return url.Parse(s)
 }
 
 type agiVar struct {
-	LogFormat LogFormat
-	LogLevel  slog.Level
-	LogOutput string
-	LogSource bool
+	CacheRefresh   bool
+	CacheTTL       time.Duration `env:"CACHE_TTL" envDefault:"1h"`
+	ListRetracted  ListRetracted `envDefault:"show"`
+	LogFormat      LogFormat
+	LogLevel       slog.Level
+	LogOutput      string
+	LogSource      bool
+	Offline        bool
+	PkgsiteURL     *url.URL      `env:"PKGSITE_URL" envDefault:"https://pkg.go.dev"`
+	RequestTimeout time.Duration `envDefault:"30s"`
+}
+
+var _ encoding.TextUnmarshaler = (*ListRetracted)(nil)
+
+// ListRetracted represents how versions that have been retracted by the
+// module's author are presented when listing versions.
+type ListRetracted int
+
+const (
+	// ListRetractedShow indicates that retracted versions are listed
+	// along with all other versions.
+	ListRetractedShow ListRetracted = iota + 1
+	// ListRetractedHide indicates that retracted versions are omitted.
+	ListRetractedHide
+	// ListRetractedAnnotate indicates that retracted versions are listed
+	// along with all other versions and that a note about each of them
+	// is written to stderr.
+	ListRetractedAnnotate
+)
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (l *ListRetracted) UnmarshalText(p []byte) error {
+	switch strings.ToLower(string(p)) {
+	case "show":
+		*l = ListRetractedShow
+
+		return nil
+	case "hide":
+		*l = ListRetractedHide
+
+		return nil
+	case "annotate":
+		*l = ListRetractedAnnotate
+
+		return nil
+	default:
+		return fmt.Errorf("%w: from \"%s\"", ErrInvalidListRetracted, string(p))
+	}
 }
 
 var _ encoding.TextUnmarshaler = (*LogFormat)(nil)
@@ -285,7 +465,7 @@ type asdfVar struct {
 	// for every script.  See the individual script documentation for
 	// more details.
 	InstallType     InstallType
-	InstallVersion  *semver.Version
+	InstallVersion  string
 	InstallPath     string
 	Concurrency     int
 	DownloadPath    string
@@ -300,6 +480,22 @@ type asdfVar struct {
 	VerboseOutput string
 }
 
+// goVar holds the go command's environment variables that also affect
+// the behavior of the plugin.
+type goVar struct {
+	GoModCache   string `env:"GOMODCACHE"`
+	GoNoProxy    string `env:"GONOPROXY"`
+	GoNoSumCheck bool   `env:"GONOSUMCHECK"`
+	GoNoSumDB    string `env:"GONOSUMDB"`
+	GoPath       string `env:"GOPATH"`
+	GoPrivate    string `env:"GOPRIVATE"`
+	GoProxy      string `env:"GOPROXY" envDefault:"https://proxy.golang.org,direct"`
+	GoSumDB      string `env:"GOSUMDB" envDefault:"sum.golang.org"`
+
+	// Home is only used to derive the go command's default GOPATH.
+	Home string `env:"HOME"`
+}
+
 const (
 	installTypeVersion = "version"
 	installTypeRef     = "ref"
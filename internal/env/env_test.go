@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
@@ -12,6 +13,7 @@ import (
 	"gotest.tools/v3/golden"
 
 	"github.com/selesy/asdf-go-install/internal/env"
+	"github.com/selesy/asdf-go-install/internal/env/envtest"
 	"github.com/selesy/asdf-go-install/internal/logger/loggertest"
 )
 
@@ -60,6 +62,19 @@ func TestNew(t *testing.T) {
 		assert.Zero(t, e.PluginPostRef())
 		assert.Zero(t, e.CmdFile())
 
+		assert.False(t, e.CacheRefresh())
+		assert.Equal(t, time.Hour, e.CacheTTL())
+		assert.Equal(t, env.ListRetractedShow, e.ListRetracted())
+		assert.False(t, e.Offline())
+		assert.Equal(t, "https://pkg.go.dev", e.PkgsiteURL().String())
+		assert.Equal(t, 30*time.Second, e.RequestTimeout())
+		assert.Equal(t, "https://proxy.golang.org,direct", e.GoProxy())
+		assert.Zero(t, e.GoNoProxy())
+		assert.Zero(t, e.GoPath())
+		assert.Equal(t, "sum.golang.org", e.GoSumDB())
+		assert.Zero(t, e.GoNoSumDB())
+		assert.False(t, e.GoNoSumCheck())
+
 		golden.Assert(t, buf.String(), "default-env-vars.log")
 	})
 
@@ -82,6 +97,179 @@ func TestNew(t *testing.T) {
 	})
 }
 
+func TestEnv_RequestTimeout(t *testing.T) {
+	t.Parallel()
+
+	log, _ := loggertest.New(t, &slog.HandlerOptions{})
+
+	e := envtest.New(t, log, []string{"AGI_REQUEST_TIMEOUT=1m30s"})
+	assert.Equal(t, 90*time.Second, e.RequestTimeout())
+}
+
+func TestEnv_PkgsiteURL(t *testing.T) {
+	t.Parallel()
+
+	t.Run("passes with mirror", func(t *testing.T) {
+		t.Parallel()
+
+		log, _ := loggertest.New(t, &slog.HandlerOptions{})
+
+		e := envtest.New(t, log, []string{"AGI_PKGSITE_URL=http://pkgsite.example.com/mirror"})
+		assert.Equal(t, "http://pkgsite.example.com/mirror", e.PkgsiteURL().String())
+	})
+
+	for _, val := range []string{"pkgsite.example.com", "ftp://pkgsite.example.com", "https://"} {
+		t.Run("fails with "+val, func(t *testing.T) {
+			t.Parallel()
+
+			log, _ := loggertest.New(t, &slog.HandlerOptions{})
+
+			e, err := env.New(log, []string{
+				"ASDF_DIR=/home/user/.asdf",
+				"ASDF_DATA_DIR=/home/user/.asdf",
+				"ASDF_CONFIG_FILE=/home/user/.asdfrc",
+				"ASDF_DEFAULT_TOOL_VERSIONS_FILENAME=.tool-versions",
+				"AGI_PKGSITE_URL=" + val,
+			})
+			require.ErrorIs(t, err, env.ErrInvalidPkgsiteURL)
+			assert.Nil(t, e)
+		})
+	}
+}
+
+func TestEnv_Cache(t *testing.T) {
+	t.Parallel()
+
+	log, _ := loggertest.New(t, &slog.HandlerOptions{})
+
+	e := envtest.New(t, log, []string{"AGI_CACHE_TTL=15m", "AGI_CACHE_REFRESH=true"})
+	assert.Equal(t, 15*time.Minute, e.CacheTTL())
+	assert.True(t, e.CacheRefresh())
+}
+
+func TestEnv_GoModCache(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		environ []string
+		exp     string
+	}{
+		{name: "unset", environ: []string{}, exp: ""},
+		{name: "HOME only", environ: []string{"HOME=/home/gopher"}, exp: "/home/gopher/go/pkg/mod"},
+		{name: "GOPATH overrides HOME", environ: []string{"HOME=/home/gopher", "GOPATH=/opt/go:/srv/go"}, exp: "/opt/go/pkg/mod"},
+		{name: "GOMODCACHE overrides GOPATH", environ: []string{"GOPATH=/opt/go", "GOMODCACHE=/var/cache/mod"}, exp: "/var/cache/mod"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			log, _ := loggertest.New(t, &slog.HandlerOptions{})
+
+			e := envtest.New(t, log, test.environ)
+			assert.Equal(t, test.exp, e.GoModCache())
+		})
+	}
+}
+
+func TestEnv_GoNoProxy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		environ []string
+		exp     string
+	}{
+		{name: "unset", environ: []string{}, exp: ""},
+		{name: "GOPRIVATE only", environ: []string{"GOPRIVATE=example.com/private"}, exp: "example.com/private"},
+		{name: "GONOPROXY overrides GOPRIVATE", environ: []string{"GOPRIVATE=example.com/private", "GONOPROXY=example.com/direct"}, exp: "example.com/direct"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			log, _ := loggertest.New(t, &slog.HandlerOptions{})
+
+			e := envtest.New(t, log, test.environ)
+			assert.Equal(t, test.exp, e.GoNoProxy())
+		})
+	}
+}
+
+func TestEnv_GoNoSumDB(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		environ []string
+		exp     string
+	}{
+		{name: "unset", environ: []string{}, exp: ""},
+		{name: "GOPRIVATE only", environ: []string{"GOPRIVATE=example.com/private"}, exp: "example.com/private"},
+		{name: "GONOSUMDB overrides GOPRIVATE", environ: []string{"GOPRIVATE=example.com/private", "GONOSUMDB=example.com/unchecked"}, exp: "example.com/unchecked"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			log, _ := loggertest.New(t, &slog.HandlerOptions{})
+
+			e := envtest.New(t, log, test.environ)
+			assert.Equal(t, test.exp, e.GoNoSumDB())
+		})
+	}
+}
+
+func TestEnv_GoSumDB(t *testing.T) {
+	t.Parallel()
+
+	log, _ := loggertest.New(t, &slog.HandlerOptions{})
+
+	e := envtest.New(t, log, []string{"GOSUMDB=sum.example.com+abcd1234+AAAA https://sum.example.com", "GONOSUMCHECK=1"})
+	assert.Equal(t, "sum.example.com+abcd1234+AAAA https://sum.example.com", e.GoSumDB())
+	assert.True(t, e.GoNoSumCheck())
+}
+
+func TestEnv_InstallVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		environ  []string
+		expQuery string
+		expVer   string
+	}{
+		{name: "unset", environ: []string{}},
+		{name: "full version", environ: []string{"ASDF_INSTALL_VERSION=v1.2.3"}, expQuery: "v1.2.3", expVer: "v1.2.3"},
+		{name: "version prefix", environ: []string{"ASDF_INSTALL_VERSION=v1.2"}, expQuery: "v1.2"},
+		{name: "latest", environ: []string{"ASDF_INSTALL_VERSION=latest"}, expQuery: "latest"},
+		{name: "comparison", environ: []string{"ASDF_INSTALL_VERSION=>=v1.4.0 <v2.0.0"}, expQuery: ">=v1.4.0 <v2.0.0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			log, _ := loggertest.New(t, &slog.HandlerOptions{})
+
+			e := envtest.New(t, log, test.environ)
+			assert.Equal(t, test.expQuery, e.InstallQuery())
+
+			if test.expVer == "" {
+				assert.Nil(t, e.InstallVersion())
+
+				return
+			}
+
+			require.NotNil(t, e.InstallVersion())
+			assert.Equal(t, test.expVer, e.InstallVersion().Original())
+		})
+	}
+}
+
 func TestLogFormat_UnmarshalText(t *testing.T) {
 	t.Parallel()
 
@@ -109,6 +297,33 @@ func TestLogFormat_UnmarshalText(t *testing.T) {
 	}
 }
 
+func TestListRetracted_UnmarshalText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		inp    string
+		expErr error
+		expVal env.ListRetracted
+	}{
+		{name: "valid ListRetracted", inp: "hide", expErr: nil, expVal: env.ListRetractedHide},
+		{name: "any case", inp: "AnNoTaTe", expErr: nil, expVal: env.ListRetractedAnnotate},
+		{name: "fails with unknown value", inp: "unknown", expErr: env.ErrInvalidListRetracted, expVal: env.ListRetracted(0)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var l env.ListRetracted
+
+			err := l.UnmarshalText([]byte(test.inp))
+			require.ErrorIs(t, err, test.expErr)
+			assert.Equal(t, test.expVal, l)
+		})
+	}
+}
+
 func TestInstallType_UnmarshalText(t *testing.T) {
 	t.Parallel()
 
@@ -2,10 +2,18 @@ package env
 
 import "errors"
 
+// ErrInvalidListRetracted is returned when the provided text cannot be
+// unmarshaled to a valid ListRetracted.
+var ErrInvalidListRetracted = errors.New("invalid list retracted mode requested")
+
 // ErrInvalidLogFormat is returned when the provided text cannot be
 // unmarshaled to a valid LogFormat.
 var ErrInvalidLogFormat = errors.New("invalid log format requested")
 
+// ErrInvalidPkgsiteURL is returned when AGI_PKGSITE_URL isn't an
+// absolute HTTP or HTTPS URL.
+var ErrInvalidPkgsiteURL = errors.New("invalid pkgsite URL requested")
+
 // ErrMarshalFailed is returned when an invalid installType is marshaled
 // to text.
 var ErrMarshalFailed = errors.New("failed to marshal install type")
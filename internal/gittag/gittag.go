@@ -0,0 +1,168 @@
+// Package gittag includes functions needed to retrieve tool versions
+// from the tags of a remote Git repository.
+package gittag
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/goget"
+	"github.com/selesy/asdf-go-install/internal/gover"
+)
+
+const remoteName = "origin"
+
+// Collector creates a gover.Collector that lists the tags of the remote
+// Git repository (typically the manifest's GitRepository) without
+// cloning it.
+//
+// Tags for nested modules are prefixed with the module's sub-directory
+// (e.g. tools/cmd/foo/v1.2.3).  The Collector returns the versions with
+// the longest prefix that contains the requested package, falling back
+// to the un-prefixed tags used by the repository's root module.  The
+// package's path within the repository can only be derived when the
+// repository's URL mirrors the import path (as it does for github.com,
+// gitlab.com, etc.) - use RootCollector for vanity import paths.
+func Collector(repo *url.URL) gover.Collector {
+	return collector(repo, func(pkg string) string {
+		return relativePath(repo, pkg)
+	})
+}
+
+// RootCollector creates a gover.Collector that lists the tags of the
+// repository declared by a go-import meta tag (see goget.Resolve.)  The
+// package's path within the repository is derived from the go-import
+// root and sub-directory so that tag prefixes are matched correctly for
+// vanity import paths.
+func RootCollector(root *goget.RepoRoot) gover.Collector {
+	return collector(root.Repo, func(pkg string) string {
+		rel, _ := root.RelativePath(pkg)
+
+		return rel
+	})
+}
+
+func collector(repo *url.URL, relative func(pkg string) string) gover.Collector {
+	return func(ctx context.Context, cfg *config.Config, pkg string) (*gover.Collection, error) {
+		cfg.Log().Debug(
+			"Listing remote tags",
+			slog.String("url", repo.String()),
+			slog.String("goal", "versions"),
+		)
+
+		rem := git.NewRemote(memory.NewStorage(), &gitconfig.RemoteConfig{
+			Name: remoteName,
+			URLs: []string{repo.String()},
+		})
+
+		if timeout := cfg.Env().RequestTimeout(); timeout > 0 {
+			var cancel context.CancelFunc
+
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		refs, err := rem.ListContext(ctx, &git.ListOptions{
+			PeelingOption: git.IgnorePeeled,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return versions(cfg, refs, relative(pkg), pkg), nil
+	}
+}
+
+func versions(cfg *config.Config, refs []*plumbing.Reference, rel string, pkg string) *gover.Collection {
+	var (
+		tags = map[string]semver.Collection{}
+		seen = map[string]struct{}{}
+	)
+
+	for _, ref := range refs {
+		if !ref.Name().IsTag() {
+			continue
+		}
+
+		name := ref.Name().Short()
+		if _, ok := seen[name]; ok {
+			continue
+		}
+
+		seen[name] = struct{}{}
+
+		prefix, str := "", name
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			prefix, str = name[:i], name[i+1:]
+		}
+
+		ver, err := gover.NewVersion(str)
+		if err != nil {
+			cfg.Log().Debug(
+				"Skipping non-version tag",
+				slog.String("tag", name),
+			)
+
+			continue
+		}
+
+		tags[prefix] = append(tags[prefix], ver)
+	}
+
+	prefix := modulePrefix(tags, rel)
+
+	cfg.Log().Debug(
+		"Selected tag prefix",
+		slog.String("package", pkg),
+		slog.String("prefix", prefix),
+	)
+
+	return gover.NewCollection(tags[prefix]...)
+}
+
+// modulePrefix returns the longest tag prefix that's a leading
+// sub-directory of the package's path relative to the repository's root
+// or an empty string (the root module's tags) if there's none.
+func modulePrefix(tags map[string]semver.Collection, rel string) string {
+	var best string
+
+	for prefix := range tags {
+		if prefix == "" || len(prefix) <= len(best) {
+			continue
+		}
+
+		if rel == prefix || strings.HasPrefix(rel, prefix+"/") {
+			best = prefix
+		}
+	}
+
+	return best
+}
+
+// relativePath returns the package's path relative to the repository's
+// root when the repository's URL mirrors the package's import path (as
+// it does for github.com, gitlab.com, etc.) or an empty string if the
+// relationship can't be determined.
+func relativePath(repo *url.URL, pkg string) string {
+	if repo.Host == "" {
+		return ""
+	}
+
+	root := repo.Host + strings.TrimSuffix(repo.Path, ".git")
+
+	rel, ok := strings.CutPrefix(pkg, root+"/")
+	if !ok {
+		return ""
+	}
+
+	return rel
+}
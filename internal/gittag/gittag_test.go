@@ -0,0 +1,190 @@
+package gittag_test
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/gittag"
+	"github.com/selesy/asdf-go-install/internal/goget"
+)
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	repo := bareRepository(t, map[string]bool{
+		"v1.0.0":               false,
+		"v1.1.0":               true,
+		"v1.2.0-rc.1":          false,
+		"release-candidate":    false,
+		"tools/v0.1.0":         false,
+		"tools/cmd/foo/v1.2.3": true,
+		"tools/cmd/foo/v1.3.0": false,
+		"suite/v9.9.9":         false,
+	})
+
+	root := &goget.RepoRoot{
+		Root: "example.com/suite",
+		VCS:  goget.VCSGit,
+		Repo: repo,
+	}
+
+	tests := map[string]struct {
+		root    *goget.RepoRoot
+		pkg     string
+		expVers string
+	}{
+		"root module": {
+			pkg:     "example.com/suite",
+			expVers: "v1.0.0 v1.1.0 v1.2.0-rc.1",
+		},
+		"package in root module": {
+			pkg:     "example.com/suite/cmd/suite",
+			expVers: "v1.0.0 v1.1.0 v1.2.0-rc.1",
+		},
+		"nested module": {
+			pkg:     "example.com/suite/tools",
+			expVers: "v0.1.0",
+		},
+		"package in nested module": {
+			pkg:     "example.com/suite/tools/cmd/bar",
+			expVers: "v0.1.0",
+		},
+		"deepest nested module": {
+			pkg:     "example.com/suite/tools/cmd/foo",
+			expVers: "v1.2.3 v1.3.0",
+		},
+		"vanity root with sub-directory": {
+			root: &goget.RepoRoot{
+				Root:   "go.example.com/tools",
+				VCS:    goget.VCSGit,
+				Repo:   repo,
+				Subdir: "tools",
+			},
+			pkg:     "go.example.com/tools/cmd/foo",
+			expVers: "v1.2.3 v1.3.0",
+		},
+		"vanity root ignores prefixes in the import path": {
+			root: &goget.RepoRoot{
+				Root: "go.example.com/suite",
+				VCS:  goget.VCSGit,
+				Repo: repo,
+			},
+			pkg:     "go.example.com/suite/cmd/tools",
+			expVers: "v1.0.0 v1.1.0 v1.2.0-rc.1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+			r := root
+			if test.root != nil {
+				r = test.root
+			}
+
+			vers, err := gittag.RootCollector(r)(context.Background(), cfg, test.pkg)
+			require.NoError(t, err)
+			assert.Equal(t, test.expVers, vers.String())
+		})
+	}
+
+	t.Run("uses root module tags without a mirrored import path", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+		vers, err := gittag.Collector(repo)(context.Background(), cfg, "example.com/suite/tools/cmd/foo")
+		require.NoError(t, err)
+		assert.Equal(t, "v1.0.0 v1.1.0 v1.2.0-rc.1", vers.String())
+	})
+
+	t.Run("fails when canceled", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		vers, err := gittag.Collector(repo)(ctx, cfg, "example.com/suite")
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, vers)
+	})
+
+	t.Run("fails without repository", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+		vers, err := gittag.Collector(&url.URL{Path: filepath.Join(t.TempDir(), "missing.git")})(context.Background(), cfg, "example.com/suite")
+		require.Error(t, err)
+		assert.Nil(t, vers)
+	})
+}
+
+// bareRepository creates a bare Git repository with a single commit
+// that's tagged with each of the provided names.  Tags are annotated
+// when their value is true and lightweight otherwise.
+func bareRepository(t *testing.T, tags map[string]bool) *url.URL {
+	t.Helper()
+
+	workDir := filepath.Join(t.TempDir(), "work")
+
+	work, err := git.PlainInit(workDir, false)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "go.mod"), []byte("module example.com/suite\n"), 0o600))
+
+	tree, err := work.Worktree()
+	require.NoError(t, err)
+
+	_, err = tree.Add("go.mod")
+	require.NoError(t, err)
+
+	sig := &object.Signature{
+		Name:  "Test",
+		Email: "test@example.com",
+		When:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	hash, err := tree.Commit("Initial commit", &git.CommitOptions{
+		Author: sig,
+	})
+	require.NoError(t, err)
+
+	for name, annotated := range tags {
+		var opts *git.CreateTagOptions
+
+		if annotated {
+			opts = &git.CreateTagOptions{
+				Tagger:  sig,
+				Message: name,
+			}
+		}
+
+		_, err := work.CreateTag(name, hash, opts)
+		require.NoError(t, err)
+	}
+
+	bareDir := filepath.Join(t.TempDir(), "suite.git")
+
+	_, err = git.PlainClone(bareDir, true, &git.CloneOptions{
+		URL: workDir,
+	})
+	require.NoError(t, err)
+
+	return &url.URL{Path: bareDir}
+}
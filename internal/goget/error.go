@@ -0,0 +1,24 @@
+package goget
+
+import "errors"
+
+// ErrAmbiguousImport is returned when more than one go-import meta tag
+// matches the requested import path.
+var ErrAmbiguousImport = errors.New("multiple go-import meta tags match import path")
+
+// ErrInvalidMeta is returned when the content of a go-import or
+// go-source meta tag can't be parsed.
+var ErrInvalidMeta = errors.New("invalid go-get meta tag")
+
+// ErrNoImport is returned when no go-import meta tag matches the
+// requested import path.
+var ErrNoImport = errors.New("no go-import meta tag matches import path")
+
+// ErrRootMismatch is returned when the page served for a repository
+// root doesn't declare the same repository as the page served for the
+// requested import path.
+var ErrRootMismatch = errors.New("go-import meta tag doesn't match the repository root")
+
+// ErrUnsupportedVCS is returned when the repository's version control
+// system isn't Git.
+var ErrUnsupportedVCS = errors.New("unsupported version control system")
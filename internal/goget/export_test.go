@@ -0,0 +1,8 @@
+package goget
+
+// ResolveWithTransport allows tests to redirect the requests for the
+// go-get meta tags to a test server.
+var ResolveWithTransport = resolve
+
+// RepositoryOf allows tests to check the URL selected from a RepoRoot.
+var RepositoryOf = repository
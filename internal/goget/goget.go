@@ -0,0 +1,287 @@
+// Package goget includes functions needed to discover the repository
+// of a Go package using the same "?go-get=1" meta tags as the go
+// command.  This allows tools published under vanity import paths to be
+// resolved without relying on pkg.go.dev.
+//
+// See [Remote import paths] for the format of the go-import meta tag
+// and [go-source] for the format of the go-source meta tag.
+//
+// [Remote import paths]: https://pkg.go.dev/cmd/go#hdr-Remote_import_paths
+// [go-source]: https://github.com/golang/gddo/wiki/Source-Code-Links
+package goget
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/lmittmann/tint"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/transport"
+)
+
+const (
+	// VCSGit is the go-import VCS type for Git repositories.
+	VCSGit = "git"
+
+	// VCSMod is the go-import VCS type for modules served from a module
+	// proxy instead of a version control repository.
+	VCSMod = "mod"
+
+	goGetQuery = "go-get=1"
+)
+
+// RepoRoot describes the repository of a Go package as declared by the
+// go-import and go-source meta tags.
+type RepoRoot struct {
+	// Root is the import path prefix that corresponds to the root of
+	// the repository (typically the module path.)
+	Root string
+
+	// VCS is the repository's version control system (e.g. git.)
+	VCS string
+
+	// Repo is the URL of the repository.
+	Repo *url.URL
+
+	// Subdir is the repository's sub-directory that contains the root
+	// (or an empty string.)
+	Subdir string
+
+	// Source contains the repository's source code links or nil if
+	// the go-source meta tag isn't present.
+	Source *Source
+}
+
+// RelativePath returns the path of the package's directory relative to
+// the root of the repository (including the Subdir) and true, or false
+// if the package isn't within the Root.
+func (r *RepoRoot) RelativePath(pkg string) (string, bool) {
+	if pkg != r.Root && !strings.HasPrefix(pkg, r.Root+"/") {
+		return "", false
+	}
+
+	return path.Join(r.Subdir, strings.TrimPrefix(strings.TrimPrefix(pkg, r.Root), "/")), true
+}
+
+// Source describes the links to a repository's source code as declared
+// by the go-source meta tag.
+type Source struct {
+	Home      string
+	Directory string
+	File      string
+}
+
+// Resolve discovers the RepoRoot of the Go package by requesting
+// https://<pkg>?go-get=1 and matching the go-import meta tags in the
+// response against the package's import path.  As with the go command,
+// the response for the repository root is also requested to confirm
+// that the root declares the same repository.
+func Resolve(ctx context.Context, cfg *config.Config, pkg string) (*RepoRoot, error) {
+	return resolve(ctx, cfg, transport.New(ctx, cfg), pkg)
+}
+
+// Repository discovers the URL of the Go package's Git repository (see
+// Resolve.)  The signature matches pkgsite.Repository so that either can
+// be used to create a manifest.Manifest.
+func Repository(ctx context.Context, cfg *config.Config, pkg string) (*url.URL, error) {
+	root, err := Resolve(ctx, cfg, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	return repository(root)
+}
+
+func repository(root *RepoRoot) (*url.URL, error) {
+	if root.VCS != VCSGit {
+		return nil, fmt.Errorf("%w: %s serves a %s repository", ErrUnsupportedVCS, root.Root, root.VCS)
+	}
+
+	return root.Repo, nil
+}
+
+func resolve(ctx context.Context, cfg *config.Config, next http.RoundTripper, pkg string) (*RepoRoot, error) {
+	root, err := fetch(ctx, cfg, next, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	if root.Root == pkg {
+		return root, nil
+	}
+
+	check, err := fetch(ctx, cfg, next, root.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	if check.Root != root.Root || check.VCS != root.VCS || check.Repo.String() != root.Repo.String() {
+		return nil, fmt.Errorf("%w: %s declares %s %s but %s declares %s %s", ErrRootMismatch, pkg, root.VCS, root.Repo, check.Root, check.VCS, check.Repo)
+	}
+
+	return root, nil
+}
+
+// fetch requests the go-get meta tags for the import path and returns
+// the RepoRoot that matches it.
+func fetch(ctx context.Context, cfg *config.Config, next http.RoundTripper, importPath string) (*RepoRoot, error) {
+	host, dir, _ := strings.Cut(importPath, "/")
+
+	u := &url.URL{
+		Scheme:   "https",
+		Host:     host,
+		Path:     "/" + dir,
+		RawQuery: goGetQuery,
+	}
+
+	cfg.Log().Debug(
+		"Scraping go-get meta tags",
+		slog.String("url", u.String()),
+		slog.String("goal", "repository"),
+	)
+
+	col := colly.NewCollector()
+	col.SetRequestTimeout(cfg.Env().RequestTimeout())
+	col.WithTransport(transport.WithContext(ctx, next))
+
+	// The go command accepts meta tags served with an error status so
+	// that they can be included in a 404 page.
+	col.ParseHTTPErrorResponse = true
+
+	var (
+		imports []*RepoRoot
+		sources = map[string]*Source{}
+		err     error
+	)
+
+	col.OnError(func(_ *colly.Response, e error) {
+		cfg.Log().Error("Colly error", tint.Err(e))
+		err = e
+	})
+
+	col.OnHTML("head meta[name=go-import]", func(h *colly.HTMLElement) {
+		root, e := parseImport(h.Attr("content"))
+		if e != nil {
+			cfg.Log().Warn("Skipping invalid go-import meta tag", slog.String("url", u.String()), tint.Err(e))
+
+			return
+		}
+
+		imports = append(imports, root)
+	})
+
+	col.OnHTML("head meta[name=go-source]", func(h *colly.HTMLElement) {
+		prefix, src, e := parseSource(h.Attr("content"))
+		if e != nil {
+			cfg.Log().Warn("Skipping invalid go-source meta tag", slog.String("url", u.String()), tint.Err(e))
+
+			return
+		}
+
+		sources[prefix] = src
+	})
+
+	if e := col.Visit(u.String()); e != nil && err == nil {
+		err = e
+	}
+
+	col.Wait()
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := match(imports, importPath)
+	if err != nil {
+		return nil, err
+	}
+
+	root.Source = sources[root.Root]
+
+	return root, nil
+}
+
+// match selects the RepoRoot whose root is the import path or one of its
+// parents.  Version control repositories are preferred over the module
+// proxies declared with the mod VCS type.
+func match(imports []*RepoRoot, importPath string) (*RepoRoot, error) {
+	var found *RepoRoot
+
+	for _, root := range imports {
+		if importPath != root.Root && !strings.HasPrefix(importPath, root.Root+"/") {
+			continue
+		}
+
+		switch {
+		case found == nil:
+			found = root
+		case found.VCS == VCSMod && root.VCS != VCSMod:
+			found = root
+		case found.VCS != VCSMod && root.VCS == VCSMod:
+		default:
+			return nil, fmt.Errorf("%w: %s (%s and %s)", ErrAmbiguousImport, importPath, found.Root, root.Root)
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoImport, importPath)
+	}
+
+	return found, nil
+}
+
+// parseImport parses the "root-path vcs repo-url [subdirectory]"
+// content of a go-import meta tag.
+func parseImport(content string) (*RepoRoot, error) {
+	fields := strings.Fields(content)
+	if len(fields) != 3 && len(fields) != 4 {
+		return nil, fmt.Errorf("%w: go-import %q", ErrInvalidMeta, content)
+	}
+
+	repo, err := url.Parse(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: go-import %q: %w", ErrInvalidMeta, content, err)
+	}
+
+	if !repo.IsAbs() {
+		return nil, fmt.Errorf("%w: go-import %q: repository URL must be absolute", ErrInvalidMeta, content)
+	}
+
+	root := &RepoRoot{
+		Root: fields[0],
+		VCS:  fields[1],
+		Repo: repo,
+	}
+
+	if len(fields) == 4 {
+		root.Subdir = fields[3]
+	}
+
+	return root, nil
+}
+
+// parseSource parses the "prefix home directory file" content of a
+// go-source meta tag.
+func parseSource(content string) (string, *Source, error) {
+	fields := strings.Fields(content)
+	if len(fields) != 4 {
+		return "", nil, fmt.Errorf("%w: go-source %q", ErrInvalidMeta, content)
+	}
+
+	return fields[0], &Source{
+		Home:      fields[1],
+		Directory: fields[2],
+		File:      fields[3],
+	}, nil
+}
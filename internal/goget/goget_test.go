@@ -0,0 +1,253 @@
+package goget_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/goget"
+	"github.com/selesy/asdf-go-install/internal/transport"
+)
+
+// pages maps the host and path of each go-get request to the meta tags
+// served in response.
+var pages = map[string]string{
+	"go.example.com/tool": `
+		<meta name="go-import" content="go.example.com/tool git https://git.example.com/tool.git">
+		<meta name="go-source" content="go.example.com/tool https://git.example.com/tool https://git.example.com/tool/tree/main{/dir} https://git.example.com/tool/blob/main{/dir}/{file}#L{line}">`,
+	"go.example.com/tool/cmd/tool": `
+		<meta name="go-import" content="go.example.com/tool git https://git.example.com/tool.git">
+		<meta name="go-import" content="go.example.com/tool mod https://proxy.example.com">`,
+	"go.example.com/nested/cmd/nested": `
+		<meta name="go-import" content="go.example.com/nested git https://git.example.com/monorepo.git tools/nested">`,
+	"go.example.com/nested": `
+		<meta name="go-import" content="go.example.com/nested git https://git.example.com/monorepo.git tools/nested">`,
+	"go.example.com/moved/cmd/moved": `
+		<meta name="go-import" content="go.example.com/moved git https://git.example.com/moved.git">`,
+	"go.example.com/moved": `
+		<meta name="go-import" content="go.example.com/moved git https://git.example.com/other.git">`,
+	"go.example.com/ambiguous/cmd/tool": `
+		<meta name="go-import" content="go.example.com/ambiguous git https://git.example.com/a.git">
+		<meta name="go-import" content="go.example.com/ambiguous/cmd git https://git.example.com/b.git">`,
+	"go.example.com/hg": `
+		<meta name="go-import" content="go.example.com/hg hg https://hg.example.com/hg">`,
+	"go.example.com/invalid": `
+		<meta name="go-import" content="go.example.com/invalid git">`,
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	srv, next := newServer(t)
+	t.Cleanup(srv.Close)
+
+	tests := map[string]struct {
+		pkg       string
+		expRoot   string
+		expRepo   string
+		expSubdir string
+		expSource bool
+		expErr    error
+	}{
+		"pass with repository root": {
+			pkg:       "go.example.com/tool",
+			expRoot:   "go.example.com/tool",
+			expRepo:   "https://git.example.com/tool.git",
+			expSource: true,
+		},
+		"pass with package served as 404 and module proxy": {
+			pkg:     "go.example.com/tool/cmd/tool",
+			expRoot: "go.example.com/tool",
+			expRepo: "https://git.example.com/tool.git",
+		},
+		"pass with sub-directory": {
+			pkg:       "go.example.com/nested/cmd/nested",
+			expRoot:   "go.example.com/nested",
+			expRepo:   "https://git.example.com/monorepo.git",
+			expSubdir: "tools/nested",
+		},
+		"fail when root declares a different repository": {
+			pkg:    "go.example.com/moved/cmd/moved",
+			expErr: goget.ErrRootMismatch,
+		},
+		"fail with ambiguous import": {
+			pkg:    "go.example.com/ambiguous/cmd/tool",
+			expErr: goget.ErrAmbiguousImport,
+		},
+		"fail without matching meta tag": {
+			pkg:    "go.example.com/missing",
+			expErr: goget.ErrNoImport,
+		},
+		"fail with only invalid meta tags": {
+			pkg:    "go.example.com/invalid",
+			expErr: goget.ErrNoImport,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+			root, err := goget.ResolveWithTransport(context.Background(), cfg, next, test.pkg)
+			require.ErrorIs(t, err, test.expErr)
+
+			if err != nil {
+				assert.Nil(t, root)
+
+				return
+			}
+
+			assert.Equal(t, test.expRoot, root.Root)
+			assert.Equal(t, goget.VCSGit, root.VCS)
+			assert.Equal(t, test.expRepo, root.Repo.String())
+			assert.Equal(t, test.expSubdir, root.Subdir)
+			assert.Equal(t, test.expSource, root.Source != nil)
+		})
+	}
+}
+
+func TestResolve_Source(t *testing.T) {
+	t.Parallel()
+
+	srv, next := newServer(t)
+	t.Cleanup(srv.Close)
+
+	cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+	root, err := goget.ResolveWithTransport(context.Background(), cfg, next, "go.example.com/tool")
+	require.NoError(t, err)
+	require.NotNil(t, root.Source)
+	assert.Equal(t, "https://git.example.com/tool", root.Source.Home)
+	assert.Equal(t, "https://git.example.com/tool/tree/main{/dir}", root.Source.Directory)
+	assert.Equal(t, "https://git.example.com/tool/blob/main{/dir}/{file}#L{line}", root.Source.File)
+}
+
+func TestResolve_Offline(t *testing.T) {
+	t.Parallel()
+
+	srv, next := newServer(t)
+	t.Cleanup(srv.Close)
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"AGI_OFFLINE=true"}, []string{})
+
+	root, err := goget.ResolveWithTransport(context.Background(), cfg, transport.Offline(cfg, next), "go.example.com/tool")
+	require.ErrorIs(t, err, transport.ErrOffline)
+	assert.Nil(t, root)
+}
+
+func TestRepoRoot_RelativePath(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		subdir string
+		pkg    string
+		expRel string
+		expOK  bool
+	}{
+		"pass with root": {
+			pkg:   "go.example.com/tool",
+			expOK: true,
+		},
+		"pass with package": {
+			pkg:    "go.example.com/tool/cmd/tool",
+			expRel: "cmd/tool",
+			expOK:  true,
+		},
+		"pass with sub-directory": {
+			subdir: "tools/nested",
+			pkg:    "go.example.com/tool/cmd/tool",
+			expRel: "tools/nested/cmd/tool",
+			expOK:  true,
+		},
+		"fail with sibling path": {
+			pkg: "go.example.com/toolbox",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root := &goget.RepoRoot{
+				Root:   "go.example.com/tool",
+				Subdir: test.subdir,
+			}
+
+			rel, ok := root.RelativePath(test.pkg)
+			assert.Equal(t, test.expOK, ok)
+			assert.Equal(t, test.expRel, rel)
+		})
+	}
+}
+
+func TestRepository(t *testing.T) {
+	t.Parallel()
+
+	srv, next := newServer(t)
+	t.Cleanup(srv.Close)
+
+	cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+	t.Run("Git", func(t *testing.T) {
+		t.Parallel()
+
+		root, err := goget.ResolveWithTransport(context.Background(), cfg, next, "go.example.com/tool")
+		require.NoError(t, err)
+
+		repo, err := goget.RepositoryOf(root)
+		require.NoError(t, err)
+		assert.Equal(t, "https://git.example.com/tool.git", repo.String())
+	})
+
+	t.Run("Mercurial", func(t *testing.T) {
+		t.Parallel()
+
+		root, err := goget.ResolveWithTransport(context.Background(), cfg, next, "go.example.com/hg")
+		require.NoError(t, err)
+
+		repo, err := goget.RepositoryOf(root)
+		require.ErrorIs(t, err, goget.ErrUnsupportedVCS)
+		assert.Nil(t, repo)
+	})
+}
+
+// newServer starts a TLS server that serves the go-get pages and returns
+// a transport that redirects every request to it.  Pages for packages
+// below a repository root are served with a 404 (Not Found) status.
+func newServer(t *testing.T) (*httptest.Server, http.RoundTripper) {
+	t.Helper()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1", r.URL.Query().Get("go-get"))
+
+		meta, ok := pages[r.Host+r.URL.Path]
+		if !ok || strings.Contains(r.URL.Path, "/cmd/") {
+			w.WriteHeader(http.StatusNotFound)
+		}
+
+		_, _ = fmt.Fprintf(w, "<!DOCTYPE html><html><head>%s</head><body>go get</body></html>", meta)
+	}))
+
+	return srv, redirect(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.Host = r.URL.Host
+		r.URL.Host = srv.Listener.Addr().String()
+
+		return srv.Client().Transport.RoundTrip(r)
+	})
+}
+
+type redirect func(*http.Request) (*http.Response, error)
+
+func (f redirect) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
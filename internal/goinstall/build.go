@@ -0,0 +1,148 @@
+package goinstall
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// BuildOptions are the per-tool settings stored in a plugin's manifest
+// that change how the go command builds the tool.  The zero value
+// builds the tool with the go command's defaults.
+type BuildOptions struct {
+	// Tags are the build tags passed to the -tags flag.
+	Tags []string `json:"tags,omitempty"`
+
+	// CGOEnabled sets CGO_ENABLED when it isn't nil.
+	CGOEnabled *bool `json:"cgoEnabled,omitempty"`
+
+	// GoFlags are the flags set in GOFLAGS.
+	GoFlags []string `json:"goFlags,omitempty"`
+
+	// LDFlags is the value passed to the -ldflags flag.
+	LDFlags string `json:"ldFlags,omitempty"`
+
+	// TrimPath indicates that the -trimpath flag is passed.
+	TrimPath bool `json:"trimPath,omitempty"`
+
+	// GoExperiment are the experiments set in GOEXPERIMENT.
+	GoExperiment []string `json:"goExperiment,omitempty"`
+
+	// Env contains additional environment variables for the go command.
+	// The variables set by the other options take precedence.
+	Env map[string]string `json:"env,omitempty"`
+
+	// BinaryName replaces the name of the installed binary, which is
+	// otherwise the last element of the package path (see BinaryName.)
+	BinaryName string `json:"binaryName,omitempty"`
+}
+
+// Validate returns ErrInvalidBuildOptions if the environment variable
+// names or binary name can't be used.  A nil BuildOptions is valid.
+func (o *BuildOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+
+	for key := range o.Env {
+		if key == "" || strings.ContainsAny(key, "= \t\n") {
+			return fmt.Errorf("%w: environment variable name %q", ErrInvalidBuildOptions, key)
+		}
+	}
+
+	if name := o.BinaryName; name != "" && (name != filepath.Base(name) || name == "." || name == ".." || strings.ContainsAny(name, `/\`)) {
+		return fmt.Errorf("%w: binary name %q", ErrInvalidBuildOptions, name)
+	}
+
+	return nil
+}
+
+// args returns the go command's build flags.
+func (o *BuildOptions) args() []string {
+	if o == nil {
+		return nil
+	}
+
+	var args []string
+
+	if o.TrimPath {
+		args = append(args, "-trimpath")
+	}
+
+	if len(o.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(o.Tags, ","))
+	}
+
+	if o.LDFlags != "" {
+		args = append(args, "-ldflags", o.LDFlags)
+	}
+
+	return args
+}
+
+// env returns the go command's environment variables sorted so that the
+// command is reproducible.
+func (o *BuildOptions) env() []string {
+	if o == nil {
+		return nil
+	}
+
+	var env []string
+
+	for _, key := range slices.Sorted(maps.Keys(o.Env)) {
+		env = append(env, key+"="+o.Env[key])
+	}
+
+	switch {
+	case o.CGOEnabled == nil:
+	case *o.CGOEnabled:
+		env = append(env, "CGO_ENABLED=1")
+	default:
+		env = append(env, "CGO_ENABLED=0")
+	}
+
+	if len(o.GoFlags) > 0 {
+		env = append(env, "GOFLAGS="+strings.Join(o.GoFlags, " "))
+	}
+
+	if len(o.GoExperiment) > 0 {
+		env = append(env, "GOEXPERIMENT="+strings.Join(o.GoExperiment, ","))
+	}
+
+	return env
+}
+
+// BinaryName returns the name of the binary that's installed for the
+// package.  The go command names the binary after the last element of
+// the package path, skipping a /vN major version suffix (e.g. tool for
+// example.com/tool/v2.)  The BuildOptions' BinaryName overrides it.
+func BinaryName(pkg string, opts *BuildOptions) string {
+	if opts != nil && opts.BinaryName != "" {
+		return opts.BinaryName
+	}
+
+	if prefix, pathMajor, ok := module.SplitPathVersion(pkg); ok && strings.HasPrefix(pathMajor, "/") {
+		pkg = prefix
+	}
+
+	return path.Base(pkg)
+}
+
+// rename moves the binary installed by the go command to the name set
+// by the BuildOptions.
+func rename(binDir string, pkg string, opts *BuildOptions) error {
+	name := BinaryName(pkg, opts)
+	installed := BinaryName(pkg, nil)
+
+	if name == installed {
+		return nil
+	}
+
+	return os.Rename(filepath.Join(binDir, installed), filepath.Join(binDir, name))
+}
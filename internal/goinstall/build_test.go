@@ -0,0 +1,117 @@
+package goinstall_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/goinstall"
+)
+
+func TestCommand_BuildOptions(t *testing.T) {
+	t.Parallel()
+
+	cgo := false
+
+	opts := &goinstall.BuildOptions{
+		Tags:         []string{"netgo", "osusergo"},
+		CGOEnabled:   &cgo,
+		GoFlags:      []string{"-mod=mod", "-buildvcs=false"},
+		LDFlags:      "-s -w -X main.version=v1.1.0",
+		TrimPath:     true,
+		GoExperiment: []string{"rangefunc", "aliastypeparams"},
+		Env: map[string]string{
+			"GOBIN":   "/tmp/ignored",
+			"GOAMD64": "v3",
+		},
+		BinaryName: "tool2",
+	}
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"ASDF_INSTALL_PATH=/opt/tool"}, []string{})
+
+	cmd := goinstall.Command(context.Background(), cfg, &goinstall.Target{Package: pkg, Version: "v1.1.0"}, opts)
+	assert.Equal(t, []string{
+		"go", "install",
+		"-trimpath",
+		"-tags", "netgo,osusergo",
+		"-ldflags", "-s -w -X main.version=v1.1.0",
+		pkg + "@v1.1.0",
+	}, cmd.Args)
+
+	env := cmd.Env[len(cmd.Env)-6:]
+	assert.Equal(t, []string{
+		"GOAMD64=v3",
+		"GOBIN=/tmp/ignored",
+		"CGO_ENABLED=0",
+		"GOFLAGS=-mod=mod -buildvcs=false",
+		"GOEXPERIMENT=rangefunc,aliastypeparams",
+		"GOBIN=/opt/tool/bin",
+	}, env)
+}
+
+func TestCommand_DefaultBuildOptions(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+	for _, opts := range []*goinstall.BuildOptions{nil, {}} {
+		cmd := goinstall.Command(context.Background(), cfg, &goinstall.Target{Package: pkg, Version: "v1.1.0"}, opts)
+		assert.Equal(t, []string{"go", "install", pkg + "@v1.1.0"}, cmd.Args)
+		assert.False(t, slices.ContainsFunc(cmd.Env, func(v string) bool {
+			return v == "CGO_ENABLED=0" || v == "CGO_ENABLED=1"
+		}))
+	}
+}
+
+func TestBuildOptions_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		opts   *goinstall.BuildOptions
+		expErr error
+	}{
+		"nil":                       {},
+		"binary name":               {opts: &goinstall.BuildOptions{BinaryName: "tool2"}},
+		"environment variable":      {opts: &goinstall.BuildOptions{Env: map[string]string{"GOAMD64": "v3"}}},
+		"binary name with path":     {opts: &goinstall.BuildOptions{BinaryName: "../tool"}, expErr: goinstall.ErrInvalidBuildOptions},
+		"binary name is dot-dot":    {opts: &goinstall.BuildOptions{BinaryName: ".."}, expErr: goinstall.ErrInvalidBuildOptions},
+		"empty variable name":       {opts: &goinstall.BuildOptions{Env: map[string]string{"": "x"}}, expErr: goinstall.ErrInvalidBuildOptions},
+		"variable name with equals": {opts: &goinstall.BuildOptions{Env: map[string]string{"A=B": "x"}}, expErr: goinstall.ErrInvalidBuildOptions},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.ErrorIs(t, test.opts.Validate(), test.expErr)
+		})
+	}
+}
+
+func TestBinaryName(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		pkg  string
+		opts *goinstall.BuildOptions
+		exp  string
+	}{
+		"package":                {pkg: "example.com/tool/cmd/tool", exp: "tool"},
+		"major version suffix":   {pkg: "example.com/tool/v2", exp: "tool"},
+		"major version sub-path": {pkg: "example.com/tool/v2/cmd/tool", exp: "tool"},
+		"gopkg.in module":        {pkg: "gopkg.in/yaml.v3", exp: "yaml.v3"},
+		"override":               {pkg: "example.com/tool/cmd/tool", opts: &goinstall.BuildOptions{BinaryName: "tool2"}, exp: "tool2"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.exp, goinstall.BinaryName(test.pkg, test.opts))
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package goinstall
+
+import "errors"
+
+// ErrDuplicateBinary is returned when two of a plugin's Packages
+// would be installed with the same binary name.
+var ErrDuplicateBinary = errors.New("duplicate binary name")
+
+// ErrInvalidBuildOptions is returned when a manifest's BuildOptions
+// can't be passed to the go command.
+var ErrInvalidBuildOptions = errors.New("invalid build options")
+
+// ErrPackageNotInModule is returned when one of a plugin's Packages
+// isn't provided by the module that's being installed, since all of a
+// plugin's Packages are installed from a single module.
+var ErrPackageNotInModule = errors.New("package not in module")
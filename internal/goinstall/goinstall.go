@@ -0,0 +1,294 @@
+// Package goinstall resolves the version of a Go package that asdf has
+// requested and prepares the "go install" command that installs it.
+package goinstall
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/module"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/env"
+	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/modcache"
+	"github.com/selesy/asdf-go-install/internal/sumdb"
+	"github.com/selesy/asdf-go-install/internal/transport"
+)
+
+// Target is the package path and version that are passed to the go
+// command as "go install package@version".
+type Target struct {
+	Package string
+	Version string
+
+	// Module is the path of the module that published the Version or
+	// an empty string if it's unknown.
+	Module string
+}
+
+// String returns the Target formatted as a "go install" argument.
+func (t Target) String() string {
+	return t.Package + "@" + t.Version
+}
+
+// Resolve returns the concrete Go module version number that should be
+// installed for the version requested by asdf (ASDF_INSTALL_VERSION.)
+//
+// The request is resolved as a version query (e.g. v1.2.3, v1.2, latest
+// or >=v1.4.0 <v2.0.0) against the versions returned by the Collector.
+// See gover.Collection.Resolve for the supported queries.  asdf doesn't
+// provide the currently installed version, so the upgrade and patch
+// queries are equivalent to latest.
+func Resolve(ctx context.Context, cfg *config.Config, collect gover.Collector, pkg string) (*gover.Resolution, error) {
+	return resolve(ctx, cfg, collect, pkg, cfg.Env().InstallQuery())
+}
+
+// LatestStable returns the Go module version number reported by
+// bin/latest-stable.  The optional query (e.g. v1.2) limits the result
+// to matching versions and defaults to gover.QueryLatest.
+func LatestStable(ctx context.Context, cfg *config.Config, collect gover.Collector, pkg string, query string) (*gover.Resolution, error) {
+	if query == "" {
+		query = gover.QueryLatest
+	}
+
+	return resolve(ctx, cfg, collect, pkg, query)
+}
+
+// List writes the versions reported by bin/list-all to stdout as a
+// space-delimited list.  Retracted versions are included, omitted or
+// noted on stderr as requested by AGI_LIST_RETRACTED (see
+// env.ListRetracted.)  Only bare versions are written to stdout since
+// asdf splits the output on whitespace.
+func List(ctx context.Context, cfg *config.Config, collect gover.Collector, pkg string, stdout io.Writer, stderr io.Writer) error {
+	col, err := collect(ctx, cfg, pkg)
+	if err != nil {
+		return err
+	}
+
+	switch cfg.Env().ListRetracted() {
+	case env.ListRetractedHide:
+		col = col.WithoutRetracted()
+	case env.ListRetractedAnnotate:
+		for _, note := range col.RetractionNotes() {
+			if _, err := fmt.Fprintln(stderr, note); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = fmt.Fprintln(stdout, col.String())
+
+	return err
+}
+
+// NewTarget returns the Target for the installation requested by asdf.
+//
+// When ASDF_INSTALL_TYPE is ref, the Git reference is passed to the go
+// command unresolved.  Otherwise, the requested version is resolved and
+// the package path is moved to the module that published the resolved
+// version (see PackagePath.)
+//
+// In offline mode, Git references can't be resolved and the resolved
+// version must be buildable from the Go module cache (see
+// modcache.Verify.)
+func NewTarget(ctx context.Context, cfg *config.Config, collect gover.Collector, pkg string) (*Target, error) {
+	if cfg.Env().InstallType() == env.InstallTypeRef {
+		if cfg.Env().Offline() {
+			return nil, fmt.Errorf("%w: Git reference %s can't be resolved", transport.ErrOffline, cfg.Env().InstallQuery())
+		}
+
+		return &Target{
+			Package: pkg,
+			Version: cfg.Env().InstallQuery(),
+		}, nil
+	}
+
+	res, err := Resolve(ctx, cfg, collect, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	target := &Target{
+		Package: PackagePath(pkg, res.Module),
+		Version: res.Version.Original(),
+		Module:  res.Module,
+	}
+
+	if cfg.Env().Offline() {
+		if err := modcache.Verify(ctx, cfg, target.Module, target.Version); err != nil {
+			return nil, fmt.Errorf("%w: %s can't be installed: %w", transport.ErrOffline, target, err)
+		}
+	}
+
+	return target, nil
+}
+
+// Verify downloads the Target's module into the Go module cache with
+// "go mod download" and verifies the cached zip, which is the one that
+// "go install" builds, against the checksum database (see
+// sumdb.Verifier.Verify.)
+//
+// Targets that are unresolved Git references aren't verified since the
+// module that provides them is unknown.  The go command still verifies
+// the pseudo-version that it resolves.
+func Verify(ctx context.Context, cfg *config.Config, verifier *sumdb.Verifier, target *Target) error {
+	if target.Module == "" {
+		cfg.Log().Debug("Skipping checksum verification of unresolved target", slog.String("target", target.String()))
+
+		return nil
+	}
+
+	mod := target.Module + "@" + target.Version
+
+	cmd := goCommand(ctx, cfg, nil, "mod", "download", mod)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	cfg.Log().Debug("Downloading module", slog.String("module", mod), slog.Any("args", cmd.Args))
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go mod download %s: %w", mod, err)
+	}
+
+	data, err := modcache.Zip(cfg, target.Module, target.Version)
+	if err != nil {
+		return err
+	}
+
+	return verifier.Verify(ctx, target.Module, target.Version, data)
+}
+
+// PackagePath returns the import path of the package within the module
+// with the provided path.
+//
+// Each major version from v2 onwards is published as a separate module
+// with a major version suffix, so the package example.com/tool/cmd/tool
+// is imported as example.com/tool/v2/cmd/tool from v2.0.0 onwards.  The
+// package path is returned unchanged if the module path is empty or if
+// the package doesn't belong to any major version of the module.
+func PackagePath(pkg string, mod string) string {
+	prefix, _, ok := module.SplitPathVersion(mod)
+	if mod == "" || !ok {
+		return pkg
+	}
+
+	sub, ok := strings.CutPrefix(pkg, prefix)
+	if !ok || (sub != "" && !strings.HasPrefix(sub, "/")) {
+		return pkg
+	}
+
+	// Remove the package's own major version suffix (if any.)
+	elem, _, _ := strings.Cut(strings.TrimPrefix(sub, "/"), "/")
+	if _, pathMajor, ok := module.SplitPathVersion(prefix + "/" + elem); ok && pathMajor != "" {
+		sub = strings.TrimPrefix(sub, "/"+elem)
+	}
+
+	return mod + sub
+}
+
+// Command creates the "go install" command that installs the Target
+// into the bin directory of ASDF_INSTALL_PATH using the (optional)
+// BuildOptions.  Modules are downloaded with the plugin's GOPROXY and
+// GOSUMDB settings (see goCommand.)
+func Command(ctx context.Context, cfg *config.Config, target *Target, opts *BuildOptions) *exec.Cmd {
+	args := append([]string{"install"}, opts.args()...)
+	env := append(opts.env(), "GOBIN="+binDir(cfg))
+
+	return goCommand(ctx, cfg, env, append(args, target.String())...)
+}
+
+// Install verifies the Target's module against the checksum database
+// (see Verify) and runs the Command that installs the Target, writing
+// the go command's output to stderr.  The installed binary is then
+// renamed if the BuildOptions override its name.
+func Install(ctx context.Context, cfg *config.Config, verifier *sumdb.Verifier, target *Target, opts *BuildOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	if err := Verify(ctx, cfg, verifier, target); err != nil {
+		return err
+	}
+
+	return install(ctx, cfg, target, opts)
+}
+
+func install(ctx context.Context, cfg *config.Config, target *Target, opts *BuildOptions) error {
+	cmd := Command(ctx, cfg, target, opts)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	cfg.Log().Debug("Installing target", slog.String("target", target.String()), slog.Any("args", cmd.Args))
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go install %s: %w", target, err)
+	}
+
+	return rename(binDir(cfg), target.Package, opts)
+}
+
+// goCommand creates a go command that downloads modules with the
+// settings that the plugin was configured with, so that it uses the same
+// module proxies that versions were resolved from and the same Go module
+// cache that Verify reads.  The provided environment variables take
+// precedence over these settings.  In offline mode (AGI_OFFLINE), the go
+// command is restricted to the Go module cache with GOPROXY=off.
+func goCommand(ctx context.Context, cfg *config.Config, env []string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Env = append(os.Environ(),
+		"GONOPROXY="+cfg.Env().GoNoProxy(),
+		"GONOSUMDB="+cfg.Env().GoNoSumDB(),
+		"GOPROXY="+cfg.Env().GoProxy(),
+		"GOSUMDB="+cfg.Env().GoSumDB(),
+	)
+
+	if gopath := cfg.Env().GoPath(); gopath != "" {
+		cmd.Env = append(cmd.Env, "GOPATH="+gopath)
+	}
+
+	if modCache := cfg.Env().GoModCache(); modCache != "" {
+		cmd.Env = append(cmd.Env, "GOMODCACHE="+modCache)
+	}
+
+	cmd.Env = append(cmd.Env, env...)
+
+	if cfg.Env().Offline() {
+		cmd.Env = append(cmd.Env, "GOPROXY=off")
+	}
+
+	return cmd
+}
+
+func binDir(cfg *config.Config) string {
+	return filepath.Join(cfg.Env().InstallPath(), BinDirname)
+}
+
+func resolve(ctx context.Context, cfg *config.Config, collect gover.Collector, pkg string, query string) (*gover.Resolution, error) {
+	col, err := collect(ctx, cfg, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := col.Resolve(query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Log().Debug(
+		"Resolved version query",
+		slog.String("package", pkg),
+		slog.String("query", res.Query),
+		slog.String("version", res.Version.Original()),
+		slog.String("module", res.Module),
+		slog.String("reason", res.Reason),
+	)
+
+	return res, nil
+}
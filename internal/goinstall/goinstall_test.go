@@ -0,0 +1,535 @@
+package goinstall_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gosumdb "golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/mod/sumdb/note"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/goinstall"
+	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/gover/govertest"
+	"github.com/selesy/asdf-go-install/internal/modcache"
+	"github.com/selesy/asdf-go-install/internal/sumdb"
+	"github.com/selesy/asdf-go-install/internal/transport"
+)
+
+const pkg = "example.com/tool/cmd/tool"
+
+var errCollect = errors.New("collector should not be called")
+
+func TestNewTarget(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		environ []string
+		collect gover.Collector
+		exp     string
+		expErr  error
+	}{
+		"full version": {
+			environ: []string{"ASDF_INSTALL_TYPE=version", "ASDF_INSTALL_VERSION=v1.0.0"},
+			collect: collector(t, "v1.0.0 v1.1.0 v2.0.0"),
+			exp:     "v1.0.0",
+		},
+		"version prefix": {
+			environ: []string{"ASDF_INSTALL_TYPE=version", "ASDF_INSTALL_VERSION=v1"},
+			collect: collector(t, "v1.0.0 v1.1.0 v2.0.0"),
+			exp:     "v1.1.0",
+		},
+		"comparison": {
+			environ: []string{"ASDF_INSTALL_TYPE=version", "ASDF_INSTALL_VERSION=>=v1.1.0 <v3.0.0"},
+			collect: collector(t, "v1.0.0 v1.1.0 v2.0.0"),
+			exp:     "v2.0.0",
+		},
+		"latest": {
+			environ: []string{"ASDF_INSTALL_TYPE=version", "ASDF_INSTALL_VERSION=latest"},
+			collect: collector(t, "v1.0.0 v1.1.0 v2.0.0 v2.1.0-rc.1"),
+			exp:     "v2.0.0",
+		},
+		"Git reference isn't collected": {
+			environ: []string{"ASDF_INSTALL_TYPE=ref", "ASDF_INSTALL_VERSION=14c0d48ead0c"},
+			collect: failing,
+			exp:     "14c0d48ead0c",
+		},
+		"collector fails": {
+			environ: []string{"ASDF_INSTALL_TYPE=version", "ASDF_INSTALL_VERSION=v1"},
+			collect: failing,
+			expErr:  errCollect,
+		},
+		"no matching version": {
+			environ: []string{"ASDF_INSTALL_TYPE=version", "ASDF_INSTALL_VERSION=v3"},
+			collect: collector(t, "v1.0.0 v1.1.0 v2.0.0"),
+			expErr:  gover.ErrNoMatchingVersion,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, _ := configtest.NewConfig(t, test.environ, []string{})
+
+			target, err := goinstall.NewTarget(context.Background(), cfg, test.collect, pkg)
+			require.ErrorIs(t, err, test.expErr)
+
+			if err != nil {
+				assert.Nil(t, target)
+
+				return
+			}
+
+			assert.Equal(t, pkg, target.Package)
+			assert.Equal(t, test.exp, target.Version)
+		})
+	}
+}
+
+func TestLatestStable(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		query string
+		exp   string
+	}{
+		"without query": {
+			exp: "v2.0.0",
+		},
+		"with version prefix": {
+			query: "v1",
+			exp:   "v1.1.0",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+			res, err := goinstall.LatestStable(context.Background(), cfg, collector(t, "v1.0.0 v1.1.0 v2.0.0 v2.1.0-rc.1"), pkg, test.query)
+			require.NoError(t, err)
+			assert.Equal(t, test.exp, res.Version.Original())
+		})
+	}
+}
+
+func TestNewTarget_MajorVersion(t *testing.T) {
+	t.Parallel()
+
+	col := govertest.NewCollection(t, "v1.0.0 v1.1.0").WithModule("example.com/tool").
+		Union(govertest.NewCollection(t, "v2.0.0 v2.1.0").WithModule("example.com/tool/v2"))
+
+	collect := func(context.Context, *config.Config, string) (*gover.Collection, error) {
+		return col, nil
+	}
+
+	tests := map[string]struct {
+		query  string
+		exp    string
+		expPkg string
+	}{
+		"latest": {
+			query:  "latest",
+			exp:    "v2.1.0",
+			expPkg: "example.com/tool/v2/cmd/tool",
+		},
+		"major version 1": {
+			query:  "v1",
+			exp:    "v1.1.0",
+			expPkg: "example.com/tool/cmd/tool",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, _ := configtest.NewConfig(t, []string{"ASDF_INSTALL_TYPE=version", "ASDF_INSTALL_VERSION=" + test.query}, []string{})
+
+			target, err := goinstall.NewTarget(context.Background(), cfg, collect, pkg)
+			require.NoError(t, err)
+			assert.Equal(t, test.expPkg, target.Package)
+			assert.Equal(t, test.exp, target.Version)
+		})
+	}
+}
+
+func TestPackagePath(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		pkg string
+		mod string
+		exp string
+	}{
+		"without module":                 {pkg: "example.com/tool/cmd/tool", mod: "", exp: "example.com/tool/cmd/tool"},
+		"same module":                    {pkg: "example.com/tool/cmd/tool", mod: "example.com/tool", exp: "example.com/tool/cmd/tool"},
+		"newer major version":            {pkg: "example.com/tool/cmd/tool", mod: "example.com/tool/v2", exp: "example.com/tool/v2/cmd/tool"},
+		"package with major version":     {pkg: "example.com/tool/v2/cmd/tool", mod: "example.com/tool/v3", exp: "example.com/tool/v3/cmd/tool"},
+		"module root package":            {pkg: "example.com/tool/v2", mod: "example.com/tool/v3", exp: "example.com/tool/v3"},
+		"older major version":            {pkg: "example.com/tool/v2/cmd/tool", mod: "example.com/tool", exp: "example.com/tool/cmd/tool"},
+		"unrelated module":               {pkg: "example.com/other/cmd/tool", mod: "example.com/tool/v2", exp: "example.com/other/cmd/tool"},
+		"module path is a string prefix": {pkg: "example.com/toolbox/cmd/tool", mod: "example.com/tool/v2", exp: "example.com/toolbox/cmd/tool"},
+		"gopkg.in module":                {pkg: "gopkg.in/yaml.v2/cmd/yaml", mod: "gopkg.in/yaml.v3", exp: "gopkg.in/yaml.v2/cmd/yaml"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.exp, goinstall.PackagePath(test.pkg, test.mod))
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	col := govertest.NewCollection(t, "v1.0.0 v1.0.1 v1.1.0").WithRetractions(gover.Retraction{
+		Low:       govertest.NewVersion(t, "v1.1.0"),
+		High:      govertest.NewVersion(t, "v1.1.0"),
+		Rationale: "Published with a broken build.",
+	})
+
+	collect := func(context.Context, *config.Config, string) (*gover.Collection, error) {
+		return col, nil
+	}
+
+	tests := map[string]struct {
+		mode      string
+		expStdout string
+		expStderr string
+	}{
+		"show": {
+			mode:      "show",
+			expStdout: "v1.0.0 v1.0.1 v1.1.0\n",
+		},
+		"hide": {
+			mode:      "hide",
+			expStdout: "v1.0.0 v1.0.1\n",
+		},
+		"annotate": {
+			mode:      "annotate",
+			expStdout: "v1.0.0 v1.0.1 v1.1.0\n",
+			expStderr: "v1.1.0 is retracted: Published with a broken build.\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, _ := configtest.NewConfig(t, []string{"AGI_LIST_RETRACTED=" + test.mode}, []string{})
+
+			var stdout, stderr bytes.Buffer
+
+			require.NoError(t, goinstall.List(context.Background(), cfg, collect, pkg, &stdout, &stderr))
+			assert.Equal(t, test.expStdout, stdout.String())
+			assert.Equal(t, test.expStderr, stderr.String())
+		})
+	}
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	installPath := t.TempDir()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"ASDF_INSTALL_PATH=" + installPath}, []string{})
+
+	cmd := goinstall.Command(context.Background(), cfg, &goinstall.Target{Package: pkg, Version: "v1.1.0"}, nil)
+	assert.Equal(t, []string{"go", "install", pkg + "@v1.1.0"}, cmd.Args)
+	assert.True(t, slices.Contains(cmd.Env, "GOBIN="+filepath.Join(installPath, "bin")))
+	assert.False(t, slices.Contains(cmd.Env, "GOPROXY=off"))
+}
+
+func TestCommand_Offline(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"AGI_OFFLINE=true"}, []string{})
+
+	cmd := goinstall.Command(context.Background(), cfg, &goinstall.Target{Package: pkg, Version: "v1.1.0"}, nil)
+	assert.Equal(t, "GOPROXY=off", cmd.Env[len(cmd.Env)-1])
+}
+
+func TestNewTarget_Offline(t *testing.T) {
+	t.Parallel()
+
+	col := govertest.NewCollection(t, "v1.0.0 v1.1.0").WithModule("example.com/tool")
+
+	collect := func(context.Context, *config.Config, string) (*gover.Collection, error) {
+		return col, nil
+	}
+
+	tests := map[string]struct {
+		environ []string
+		files   []string
+		exp     string
+		expErr  error
+	}{
+		"cached": {
+			environ: []string{"ASDF_INSTALL_TYPE=version", "ASDF_INSTALL_VERSION=v1"},
+			files:   []string{"example.com/tool/@v/v1.1.0.zip", "example.com/tool/@v/v1.1.0.mod"},
+			exp:     "v1.1.0",
+		},
+		"zip isn't cached": {
+			environ: []string{"ASDF_INSTALL_TYPE=version", "ASDF_INSTALL_VERSION=v1"},
+			files:   []string{"example.com/tool/@v/v1.0.0.zip", "example.com/tool/@v/v1.1.0.mod"},
+			expErr:  modcache.ErrNotCached,
+		},
+		"Git reference": {
+			environ: []string{"ASDF_INSTALL_TYPE=ref", "ASDF_INSTALL_VERSION=14c0d48ead0c"},
+			expErr:  transport.ErrOffline,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			modCache := t.TempDir()
+
+			for _, file := range test.files {
+				path := filepath.Join(modCache, "cache", "download", filepath.FromSlash(file))
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, os.WriteFile(path, []byte("module example.com/tool\n"), 0o644))
+			}
+
+			environ := append([]string{"AGI_OFFLINE=true", "GOMODCACHE=" + modCache}, test.environ...)
+			cfg, _, _ := configtest.NewConfig(t, environ, []string{})
+
+			target, err := goinstall.NewTarget(context.Background(), cfg, collect, pkg)
+			require.ErrorIs(t, err, test.expErr)
+
+			if err != nil {
+				assert.ErrorIs(t, err, transport.ErrOffline)
+				assert.Nil(t, target)
+
+				return
+			}
+
+			assert.Equal(t, test.exp, target.Version)
+			assert.Equal(t, "example.com/tool", target.Module)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	data := moduleZip(t)
+
+	hash, err := sumdb.HashZip(data)
+	require.NoError(t, err)
+
+	modCache := cacheModule(t, data, hash)
+	gosumdb := newSumDB(t, hash)
+
+	tests := map[string]struct {
+		environ  []string
+		target   *goinstall.Target
+		recorded string
+		expErr   error
+	}{
+		"pass with cached zip": {
+			target:   &goinstall.Target{Package: pkg, Version: "v1.1.0", Module: "example.com/tool"},
+			recorded: hash,
+		},
+		"pass with cached zip offline": {
+			environ:  []string{"AGI_OFFLINE=true"},
+			target:   &goinstall.Target{Package: pkg, Version: "v1.1.0", Module: "example.com/tool"},
+			recorded: hash,
+		},
+		"pass with never verified cached zip offline": {
+			environ: []string{"AGI_OFFLINE=true"},
+			target:  &goinstall.Target{Package: pkg, Version: "v1.1.0", Module: "example.com/tool"},
+		},
+		"pass with unresolved Git reference": {
+			target: &goinstall.Target{Package: pkg, Version: "14c0d48ead0c"},
+		},
+		"fail with different hash": {
+			target:   &goinstall.Target{Package: pkg, Version: "v1.1.0", Module: "example.com/tool"},
+			recorded: "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+			expErr:   sumdb.ErrChecksumMismatch,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := newVerifyConfig(t, modCache, gosumdb, test.environ...)
+			verifier := newVerifier(t, cfg, test.recorded)
+
+			require.ErrorIs(t, goinstall.Verify(context.Background(), cfg, verifier, test.target), test.expErr)
+		})
+	}
+}
+
+func TestInstall_ChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	data := moduleZip(t)
+
+	hash, err := sumdb.HashZip(data)
+	require.NoError(t, err)
+
+	modCache := cacheModule(t, data, hash)
+	gosumdb := newSumDB(t, hash)
+	target := &goinstall.Target{Package: "example.com/tool", Version: "v1.1.0", Module: "example.com/tool"}
+
+	tests := map[string]func(context.Context, *config.Config, *sumdb.Verifier) error{
+		"Install": func(ctx context.Context, cfg *config.Config, verifier *sumdb.Verifier) error {
+			return goinstall.Install(ctx, cfg, verifier, target, nil)
+		},
+		"InstallPackages": func(ctx context.Context, cfg *config.Config, verifier *sumdb.Verifier) error {
+			return goinstall.InstallPackages(ctx, cfg, verifier, target, []*goinstall.Package{{Path: "example.com/tool"}})
+		},
+	}
+
+	for name, install := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			installPath := t.TempDir()
+			cfg := newVerifyConfig(t, modCache, gosumdb, "ASDF_INSTALL_PATH="+installPath)
+			verifier := newVerifier(t, cfg, "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")
+
+			// The go command isn't run when the module doesn't match.
+			require.ErrorIs(t, install(context.Background(), cfg, verifier), sumdb.ErrChecksumMismatch)
+			assert.NoDirExists(t, filepath.Join(installPath, goinstall.BinDirname))
+		})
+	}
+}
+
+// cacheModule stores the module zip of example.com/tool@v1.1.0 in a Go
+// module cache as the go command does, so that "go mod download" is
+// satisfied without network access, and returns the cache's directory.
+func cacheModule(t *testing.T, data []byte, hash string) string {
+	t.Helper()
+
+	modCache := t.TempDir()
+	download := filepath.Join(modCache, "cache", "download", "example.com", "tool", "@v")
+
+	for name, content := range map[string]string{
+		filepath.Join(download, "v1.1.0.info"):                          `{"Version":"v1.1.0","Time":"2024-01-02T03:04:05Z"}`,
+		filepath.Join(download, "v1.1.0.mod"):                           "module example.com/tool\n",
+		filepath.Join(download, "v1.1.0.zip"):                           string(data),
+		filepath.Join(download, "v1.1.0.ziphash"):                       hash,
+		filepath.Join(modCache, "example.com", "tool@v1.1.0", "go.mod"): "module example.com/tool\n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+	}
+
+	return modCache
+}
+
+// newSumDB starts a checksum database that publishes the hash of
+// example.com/tool@v1.1.0 and returns its GOSUMDB setting.  The go
+// command verifies the cached go.mod file against it.
+func newSumDB(t *testing.T, hash string) string {
+	t.Helper()
+
+	const name = "sum.example.com"
+
+	skey, vkey, err := note.GenerateKey(rand.Reader, name)
+	require.NoError(t, err)
+
+	modHash, err := dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("module example.com/tool\n")), nil
+	})
+	require.NoError(t, err)
+
+	ts := gosumdb.NewTestServer(skey, func(path string, vers string) ([]byte, error) {
+		if path != "example.com/tool" || vers != "v1.1.0" {
+			return nil, fs.ErrNotExist
+		}
+
+		return []byte(fmt.Sprintf("%s %s %s\n%s %s/go.mod %s\n", path, vers, hash, path, vers, modHash)), nil
+	})
+
+	srv := httptest.NewServer(gosumdb.NewServer(ts))
+	t.Cleanup(srv.Close)
+
+	return vkey + " " + srv.URL
+}
+
+// newVerifyConfig creates a configuration whose go command settings are
+// isolated from the user's GOPATH and Go module cache.
+func newVerifyConfig(t *testing.T, modCache string, gosumdb string, environ ...string) *config.Config {
+	t.Helper()
+
+	environ = append([]string{"GOMODCACHE=" + modCache, "GOPATH=" + t.TempDir(), "GOSUMDB=" + gosumdb}, environ...)
+	cfg, _, _ := configtest.NewConfig(t, environ, []string{})
+
+	return cfg
+}
+
+// newVerifier creates a Verifier that has recorded the hash (if any) for
+// example.com/tool@v1.1.0.
+func newVerifier(t *testing.T, cfg *config.Config, recorded string) *sumdb.Verifier {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	if recorded != "" {
+		line := "example.com/tool v1.1.0 " + recorded + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, sumdb.VerifiedFilename), []byte(line), 0o644))
+	}
+
+	verifier, err := sumdb.New(cfg, dir)
+	require.NoError(t, err)
+
+	return verifier
+}
+
+func moduleZip(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	f, err := zw.Create("example.com/tool@v1.1.0/go.mod")
+	require.NoError(t, err)
+
+	_, err = io.WriteString(f, "module example.com/tool\n")
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	return buf.Bytes()
+}
+
+func collector(t *testing.T, vers string) gover.Collector {
+	t.Helper()
+
+	col := govertest.NewCollection(t, vers)
+
+	return func(context.Context, *config.Config, string) (*gover.Collection, error) {
+		return col, nil
+	}
+}
+
+func failing(context.Context, *config.Config, string) (*gover.Collection, error) {
+	return nil, errCollect
+}
@@ -0,0 +1,104 @@
+package goinstall
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/sumdb"
+)
+
+// BinDirname is the name of the directory in ASDF_INSTALL_PATH that the
+// binaries of all of a plugin's packages are installed into.  It's the
+// only directory reported by list-bin-paths so asdf creates shims for
+// each of the binaries.
+const BinDirname = "bin"
+
+// Package is one of the packages installed by a plugin along with the
+// BuildOptions used to build it.
+//
+// A plugin can install a set of tools that must stay in lockstep (e.g.
+// several of the commands in golang.org/x/tools/cmd,) so each of its
+// Packages is installed at the same version of the same module.  Tools
+// from different modules (e.g. protoc-gen-go from
+// google.golang.org/protobuf and protoc-gen-go-grpc from
+// google.golang.org/grpc/cmd/protoc-gen-go-grpc) are versioned
+// independently, so they need a plugin each.
+type Package struct {
+	Path  string        `json:"packageName" jsonschema:"required,minLength=1"`
+	Build *BuildOptions `json:"build,omitempty"`
+}
+
+// ValidatePackages returns ErrInvalidBuildOptions if any of the
+// Packages' BuildOptions are invalid or ErrDuplicateBinary if two of the
+// Packages would be installed with the same binary name.
+func ValidatePackages(pkgs []*Package) error {
+	names := make(map[string]string, len(pkgs))
+
+	for _, pkg := range pkgs {
+		if err := pkg.Build.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", pkg.Path, err)
+		}
+
+		name := BinaryName(pkg.Path, pkg.Build)
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("%w: %s is installed by %s and %s", ErrDuplicateBinary, name, other, pkg.Path)
+		}
+
+		names[name] = pkg.Path
+	}
+
+	return nil
+}
+
+// ForPackage returns a Target that installs another package of the
+// Target's module at the same version.  The package path is adjusted for
+// the module's major version (see PackagePath.)  ErrPackageNotInModule
+// is returned if the package doesn't belong to the module.
+func (t Target) ForPackage(pkg string) (*Target, error) {
+	path := PackagePath(pkg, t.Module)
+
+	if t.Module != "" && path != t.Module && !strings.HasPrefix(path, t.Module+"/") {
+		return nil, fmt.Errorf("%w: %s isn't in %s", ErrPackageNotInModule, pkg, t.Module)
+	}
+
+	return &Target{
+		Package: path,
+		Version: t.Version,
+		Module:  t.Module,
+	}, nil
+}
+
+// InstallPackages installs each of the Packages at the Target's version
+// into the bin directory of ASDF_INSTALL_PATH (see Install.)  The
+// Packages are validated and the module is verified once before any of
+// them are installed.
+func InstallPackages(ctx context.Context, cfg *config.Config, verifier *sumdb.Verifier, target *Target, pkgs []*Package) error {
+	if err := ValidatePackages(pkgs); err != nil {
+		return err
+	}
+
+	targets := make([]*Target, 0, len(pkgs))
+
+	for _, pkg := range pkgs {
+		t, err := target.ForPackage(pkg.Path)
+		if err != nil {
+			return err
+		}
+
+		targets = append(targets, t)
+	}
+
+	if err := Verify(ctx, cfg, verifier, target); err != nil {
+		return err
+	}
+
+	for i, t := range targets {
+		if err := install(ctx, cfg, t, pkgs[i].Build); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
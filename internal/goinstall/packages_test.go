@@ -0,0 +1,147 @@
+package goinstall_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/goinstall"
+)
+
+func TestValidatePackages(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		pkgs   []*goinstall.Package
+		expErr error
+	}{
+		"single package": {
+			pkgs: []*goinstall.Package{{Path: "google.golang.org/protobuf/cmd/protoc-gen-go"}},
+		},
+		"suite": {
+			pkgs: []*goinstall.Package{
+				{Path: "golang.org/x/tools/cmd/goimports"},
+				{Path: "golang.org/x/tools/cmd/stringer"},
+				{Path: "golang.org/x/tools/cmd/callgraph", Build: &goinstall.BuildOptions{TrimPath: true}},
+			},
+		},
+		"renamed duplicate": {
+			pkgs: []*goinstall.Package{
+				{Path: "example.com/tool/cmd/tool"},
+				{Path: "example.com/tool/v2/cmd/tool", Build: &goinstall.BuildOptions{BinaryName: "tool2"}},
+			},
+		},
+		"duplicate binary": {
+			pkgs: []*goinstall.Package{
+				{Path: "example.com/tool/cmd/tool"},
+				{Path: "example.com/tool/internal/tool"},
+			},
+			expErr: goinstall.ErrDuplicateBinary,
+		},
+		"duplicate binary name override": {
+			pkgs: []*goinstall.Package{
+				{Path: "example.com/tool/cmd/tool"},
+				{Path: "example.com/tool/cmd/other", Build: &goinstall.BuildOptions{BinaryName: "tool"}},
+			},
+			expErr: goinstall.ErrDuplicateBinary,
+		},
+		"invalid build options": {
+			pkgs: []*goinstall.Package{
+				{Path: "example.com/tool/cmd/tool", Build: &goinstall.BuildOptions{BinaryName: "../tool"}},
+			},
+			expErr: goinstall.ErrInvalidBuildOptions,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.ErrorIs(t, goinstall.ValidatePackages(test.pkgs), test.expErr)
+		})
+	}
+}
+
+func TestTarget_ForPackage(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		target goinstall.Target
+		pkg    string
+		exp    string
+		expErr error
+	}{
+		"same package":          {target: goinstall.Target{Package: pkg, Version: "v1.1.0", Module: "example.com/tool"}, pkg: pkg, exp: pkg},
+		"other package":         {target: goinstall.Target{Package: pkg, Version: "v1.1.0", Module: "example.com/tool"}, pkg: "example.com/tool/cmd/other", exp: "example.com/tool/cmd/other"},
+		"module root package":   {target: goinstall.Target{Package: pkg, Version: "v1.1.0", Module: "example.com/tool"}, pkg: "example.com/tool", exp: "example.com/tool"},
+		"newer major version":   {target: goinstall.Target{Package: "example.com/tool/v2/cmd/tool", Version: "v2.0.0", Module: "example.com/tool/v2"}, pkg: "example.com/tool/cmd/other", exp: "example.com/tool/v2/cmd/other"},
+		"unknown module":        {target: goinstall.Target{Package: pkg, Version: "v1.1.0"}, pkg: "example.com/other/cmd/other", exp: "example.com/other/cmd/other"},
+		"package in other repo": {target: goinstall.Target{Package: pkg, Version: "v1.1.0", Module: "example.com/tool"}, pkg: "example.com/other/cmd/other", expErr: goinstall.ErrPackageNotInModule},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			target, err := test.target.ForPackage(test.pkg)
+			require.ErrorIs(t, err, test.expErr)
+
+			if test.expErr != nil {
+				assert.Nil(t, target)
+
+				return
+			}
+
+			assert.Equal(t, &goinstall.Target{Package: test.exp, Version: test.target.Version, Module: test.target.Module}, target)
+		})
+	}
+}
+
+func TestInstallPackages_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		target *goinstall.Target
+		pkgs   []*goinstall.Package
+		expErr error
+	}{
+		"duplicate binary": {
+			pkgs:   []*goinstall.Package{{Path: pkg}, {Path: "example.com/tool/internal/tool"}},
+			expErr: goinstall.ErrDuplicateBinary,
+		},
+		"package not in module": {
+			pkgs:   []*goinstall.Package{{Path: pkg}, {Path: "example.com/other/cmd/other"}},
+			expErr: goinstall.ErrPackageNotInModule,
+		},
+		"packages from different modules": {
+			target: &goinstall.Target{Package: "google.golang.org/protobuf/cmd/protoc-gen-go", Version: "v1.34.2", Module: "google.golang.org/protobuf"},
+			pkgs: []*goinstall.Package{
+				{Path: "google.golang.org/protobuf/cmd/protoc-gen-go"},
+				{Path: "google.golang.org/grpc/cmd/protoc-gen-go-grpc"},
+			},
+			expErr: goinstall.ErrPackageNotInModule,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			installPath := t.TempDir()
+			cfg, _, _ := configtest.NewConfig(t, []string{"ASDF_INSTALL_PATH=" + installPath}, []string{})
+
+			target := test.target
+			if target == nil {
+				target = &goinstall.Target{Package: pkg, Version: "v1.1.0", Module: "example.com/tool"}
+			}
+
+			// Nothing is installed when any of the packages are invalid.
+			require.ErrorIs(t, goinstall.InstallPackages(context.Background(), cfg, nil, target, test.pkgs), test.expErr)
+			assert.NoDirExists(t, filepath.Join(installPath, goinstall.BinDirname))
+		})
+	}
+}
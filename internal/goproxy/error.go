@@ -0,0 +1,27 @@
+package goproxy
+
+import "errors"
+
+// ErrDirect is returned when the module must be fetched directly from
+// its version control repository, either because the GOPROXY list
+// reached "direct" or because the module matches GONOPROXY.
+var ErrDirect = errors.New("module must be fetched directly from its repository")
+
+// ErrInvalidProxy is returned when an entry in the GOPROXY list can't
+// be parsed as a URL.
+var ErrInvalidProxy = errors.New("invalid module proxy")
+
+// ErrNoProxies is returned when the GOPROXY list contains no entries.
+var ErrNoProxies = errors.New("no module proxies are configured")
+
+// ErrNotFound is returned when a module proxy responds with a 404 (Not
+// Found) or 410 (Gone) status.
+var ErrNotFound = errors.New("module not found")
+
+// ErrProxyOff is returned when the GOPROXY list reaches "off" and
+// fetching modules is disallowed.
+var ErrProxyOff = errors.New("module lookup disabled by GOPROXY=off")
+
+// ErrUnexpectedStatus is returned when a module proxy responds with a
+// status other than 200 (OK), 404 (Not Found) or 410 (Gone).
+var ErrUnexpectedStatus = errors.New("unexpected response from module proxy")
@@ -0,0 +1,502 @@
+// Package goproxy includes functions needed to retrieve tool information
+// from a Go module proxy using the [GOPROXY protocol].
+//
+// [GOPROXY protocol]: https://go.dev/ref/mod#goproxy-protocol
+package goproxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/lmittmann/tint"
+	"golang.org/x/mod/module"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/transport"
+)
+
+const (
+	proxyDirect = "direct"
+	proxyOff    = "off"
+)
+
+// Proxy is a single entry from a GOPROXY list.
+type Proxy struct {
+	// URL is the base URL of the module proxy.  URL is nil when the
+	// entry is either "direct" or "off".
+	URL *url.URL
+
+	// Direct indicates that modules should be fetched directly from
+	// their version control repositories.
+	Direct bool
+
+	// Off indicates that fetching modules is disallowed.
+	Off bool
+
+	// FallbackOnError indicates that the next entry in the list should
+	// be tried after any error.  Otherwise, the next entry is only tried
+	// when this proxy responds with a 404 (Not Found) or 410 (Gone)
+	// status.
+	FallbackOnError bool
+}
+
+// ParseList parses the value of a GOPROXY environment variable into its
+// individual entries.
+//
+// Entries are separated by either a comma or a pipe.  The separator that
+// follows an entry determines whether the next entry is tried after any
+// error (pipe) or only after a "not found" response (comma.)
+func ParseList(list string) ([]Proxy, error) {
+	var proxies []Proxy
+
+	for list != "" {
+		var (
+			entry           string
+			fallbackOnError bool
+		)
+
+		if i := strings.IndexAny(list, ",|"); i >= 0 {
+			entry, fallbackOnError, list = list[:i], list[i] == '|', list[i+1:]
+		} else {
+			entry, list = list, ""
+		}
+
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		proxy := Proxy{
+			FallbackOnError: fallbackOnError,
+		}
+
+		switch entry {
+		case proxyDirect:
+			proxy.Direct = true
+		case proxyOff:
+			proxy.Off = true
+		default:
+			// As with the go command, a proxy without a scheme is
+			// assumed to be served over HTTPS.
+			if !strings.Contains(entry, "://") {
+				entry = "https://" + entry
+			}
+
+			u, err := url.Parse(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %w", ErrInvalidProxy, entry, err)
+			}
+
+			proxy.URL = u
+		}
+
+		proxies = append(proxies, proxy)
+	}
+
+	if len(proxies) == 0 {
+		return nil, ErrNoProxies
+	}
+
+	return proxies, nil
+}
+
+// Info is the JSON-encoded metadata that a module proxy returns for a
+// module version.
+type Info struct {
+	Version string
+	Time    time.Time
+}
+
+// Client retrieves module information from the list of module proxies
+// configured by the GOPROXY environment variable.
+type Client struct {
+	cfg     *config.Config
+	http    *http.Client
+	noProxy string
+	proxies []Proxy
+}
+
+// New creates a Client that uses the GOPROXY and GONOPROXY (or
+// GOPRIVATE) settings resolved by the plugin's environment.
+func New(cfg *config.Config) (*Client, error) {
+	proxies, err := ParseList(cfg.Env().GoProxy())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		cfg: cfg,
+		http: &http.Client{
+			Transport: transport.Offline(cfg, http.DefaultTransport),
+			Timeout:   cfg.Env().RequestTimeout(),
+		},
+		noProxy: cfg.Env().GoNoProxy(),
+		proxies: proxies,
+	}, nil
+}
+
+// Info returns the metadata for the provided version of the module.
+func (c *Client) Info(ctx context.Context, mod string, ver string) (*Info, error) {
+	escVer, err := module.EscapeVersion(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.get(ctx, mod, "v/"+escVer+".info")
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeInfo(data)
+}
+
+// Latest returns the metadata for the latest version of the module as
+// determined by the module proxy.
+func (c *Client) Latest(ctx context.Context, mod string) (*Info, error) {
+	data, err := c.get(ctx, mod, "latest")
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeInfo(data)
+}
+
+// GoMod returns the contents of the go.mod file for the provided version
+// of the module.
+func (c *Client) GoMod(ctx context.Context, mod string, ver string) ([]byte, error) {
+	escVer, err := module.EscapeVersion(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.get(ctx, mod, "v/"+escVer+".mod")
+}
+
+// List returns the tagged versions of the module that are known to the
+// module proxy.  The list is not sorted and excludes pseudo-versions.
+func (c *Client) List(ctx context.Context, mod string) ([]string, error) {
+	data, err := c.get(ctx, mod, "v/list")
+	if err != nil {
+		return nil, err
+	}
+
+	var vers []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if ver := strings.TrimSpace(scanner.Text()); ver != "" {
+			vers = append(vers, ver)
+		}
+	}
+
+	return vers, scanner.Err()
+}
+
+// Zip returns the contents of the module zip file for the provided
+// version of the module.
+func (c *Client) Zip(ctx context.Context, mod string, ver string) ([]byte, error) {
+	escVer, err := module.EscapeVersion(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.get(ctx, mod, "v/"+escVer+".zip")
+}
+
+// ModuleRoot returns the path of the module that provides the Go package
+// by walking up the package's path until the module proxies recognize a
+// prefix as a module.
+func (c *Client) ModuleRoot(ctx context.Context, pkg string) (string, error) {
+	mod, _, err := c.module(ctx, pkg)
+
+	return mod, err
+}
+
+// Retractions returns the retracted version ranges declared in the
+// go.mod file for the provided version of the module.
+//
+// Go only honors the retractions declared by the latest version of a
+// module, so ver should normally be the module's latest version.
+func (c *Client) Retractions(ctx context.Context, mod string, ver string) ([]gover.Retraction, error) {
+	data, err := c.GoMod(ctx, mod, ver)
+	if err != nil {
+		return nil, err
+	}
+
+	return gover.ParseRetractions(mod+"@"+ver+"/go.mod", data)
+}
+
+func (c *Client) get(ctx context.Context, mod string, suffix string) ([]byte, error) {
+	if module.MatchPrefixPatterns(c.noProxy, mod) {
+		return nil, fmt.Errorf("%w: %s matches GONOPROXY", ErrDirect, mod)
+	}
+
+	escMod, err := module.EscapePath(mod)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs error
+
+	for _, proxy := range c.proxies {
+		switch {
+		case proxy.Off:
+			return nil, errors.Join(errs, fmt.Errorf("%w: %s", ErrProxyOff, mod))
+		case proxy.Direct:
+			return nil, errors.Join(errs, fmt.Errorf("%w: %s", ErrDirect, mod))
+		}
+
+		data, err := c.fetch(ctx, proxy.URL.JoinPath(escMod, "@"+suffix))
+		if err == nil {
+			return data, nil
+		}
+
+		errs = errors.Join(errs, err)
+
+		if ctx.Err() != nil || (!proxy.FallbackOnError && !errors.Is(err, ErrNotFound)) {
+			return nil, errs
+		}
+	}
+
+	return nil, errs
+}
+
+func (c *Client) fetch(ctx context.Context, u *url.URL) ([]byte, error) {
+	c.cfg.Log().Debug(
+		"Fetching from module proxy",
+		slog.String("url", u.String()),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound, http.StatusGone:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, u)
+	default:
+		return nil, fmt.Errorf("%w: %s: %s", ErrUnexpectedStatus, u, resp.Status)
+	}
+}
+
+// module walks up the package's path until it finds the longest prefix
+// that the module proxies recognize as a module and returns that module
+// path along with its versions.
+func (c *Client) module(ctx context.Context, pkg string) (string, []string, error) {
+	lastErr := ErrNotFound
+
+	for mod := pkg; mod != "." && mod != "/"; mod = path.Dir(mod) {
+		vers, err := c.versions(ctx, mod)
+		if errors.Is(err, ErrNotFound) {
+			lastErr = err
+
+			continue
+		}
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		return mod, vers, nil
+	}
+
+	return "", nil, fmt.Errorf("no module contains package %s: %w", pkg, lastErr)
+}
+
+// versions returns the versions of the module.  A module that has only
+// pseudo-versions has an empty list but still reports its latest
+// version.
+func (c *Client) versions(ctx context.Context, mod string) ([]string, error) {
+	vers, err := c.List(ctx, mod)
+	if err != nil || len(vers) > 0 {
+		return vers, err
+	}
+
+	info, err := c.Latest(ctx, mod)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{info.Version}, nil
+}
+
+// majors probes the module paths with successively higher major version
+// suffixes (/v2, /v3, etc.) than the provided module and returns the
+// Collection for each one that the module proxies recognize.  Probing
+// stops at the first missing major version.
+//
+// The newer major versions are informational, so probing also stops
+// (without failing) if a module proxy returns any other error.
+func (c *Client) majors(ctx context.Context, mod string) ([]*gover.Collection, error) {
+	prefix, pathMajor, ok := module.SplitPathVersion(mod)
+	if !ok || strings.HasPrefix(mod, "gopkg.in/") {
+		return nil, nil
+	}
+
+	major := 1
+	if pathMajor != "" {
+		major, _ = strconv.Atoi(strings.TrimPrefix(pathMajor, "/v"))
+	}
+
+	var cols []*gover.Collection
+
+	for major++; ; major++ {
+		next := fmt.Sprintf("%s/v%d", prefix, major)
+
+		strs, err := c.versions(ctx, next)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		if err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				c.cfg.Log().Warn(
+					"Skipping newer major versions",
+					slog.String("module", next),
+					tint.Err(err),
+				)
+			}
+
+			return cols, nil
+		}
+
+		col, err := c.collection(ctx, next, strs)
+		if err != nil {
+			return nil, err
+		}
+
+		cols = append(cols, col)
+	}
+}
+
+var _ gover.Collector = Versions
+
+// Versions retrieves the available versions of the Go package from the
+// configured module proxies.
+//
+// Since each major version from v2 onwards is published as a separate
+// module with a major version suffix (e.g. example.com/tool/v2,) the
+// module proxies are also probed for newer major versions of the
+// package's module.  The returned Collection records which module
+// published each version.
+func Versions(ctx context.Context, cfg *config.Config, pkg string) (*gover.Collection, error) {
+	c, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c.http.Transport = transport.New(ctx, cfg)
+
+	mod, strs, err := c.module(ctx, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Log().Debug(
+		"Resolved module",
+		slog.String("package", pkg),
+		slog.String("module", mod),
+	)
+
+	col, err := c.collection(ctx, mod, strs)
+	if err != nil {
+		return nil, err
+	}
+
+	majors, err := c.majors(ctx, mod)
+	if err != nil {
+		return nil, err
+	}
+
+	return col.Union(majors...), nil
+}
+
+// collection parses the module's versions, skipping any that are
+// invalid, and adds the module's retractions.
+func (c *Client) collection(ctx context.Context, mod string, strs []string) (*gover.Collection, error) {
+	var vers semver.Collection
+
+	for _, str := range strs {
+		ver, err := gover.NewVersion(str)
+		if err != nil {
+			c.cfg.Log().Warn(
+				"Skipping invalid Go version",
+				slog.String("module", mod),
+				slog.String("version", str),
+				tint.Err(err),
+			)
+
+			continue
+		}
+
+		vers = append(vers, ver)
+	}
+
+	return c.withRetractions(ctx, mod, gover.NewCollection(vers...).WithModule(mod))
+}
+
+// withRetractions adds the retractions declared by the latest version
+// of the module to the Collection.  Retractions are informational, so
+// the Collection is returned unchanged if they can't be retrieved.
+func (c *Client) withRetractions(ctx context.Context, mod string, col *gover.Collection) (*gover.Collection, error) {
+	if col.Len() == 0 {
+		return col, nil
+	}
+
+	latest, err := col.LatestStable()
+	if err != nil {
+		latest = col.All()[col.Len()-1]
+	}
+
+	rs, err := c.Retractions(ctx, mod, latest.Original())
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	if err != nil {
+		c.cfg.Log().Warn(
+			"Skipping retractions",
+			slog.String("module", mod),
+			slog.String("version", latest.Original()),
+			tint.Err(err),
+		)
+
+		return col, nil
+	}
+
+	return col.WithRetractions(rs...), nil
+}
+
+func decodeInfo(data []byte) (*Info, error) {
+	var info Info
+
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
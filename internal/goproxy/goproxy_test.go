@@ -0,0 +1,369 @@
+package goproxy_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/goproxy"
+	"github.com/selesy/asdf-go-install/internal/transport"
+)
+
+func TestParseList(t *testing.T) {
+	t.Parallel()
+
+	t.Run("parses separators and keywords", func(t *testing.T) {
+		t.Parallel()
+
+		proxies, err := goproxy.ParseList("https://a.example.com|b.example.com/proxy, direct,off")
+		require.NoError(t, err)
+		require.Len(t, proxies, 4)
+
+		assert.Equal(t, "https://a.example.com", proxies[0].URL.String())
+		assert.True(t, proxies[0].FallbackOnError)
+
+		assert.Equal(t, "https://b.example.com/proxy", proxies[1].URL.String())
+		assert.False(t, proxies[1].FallbackOnError)
+
+		assert.Nil(t, proxies[2].URL)
+		assert.True(t, proxies[2].Direct)
+
+		assert.Nil(t, proxies[3].URL)
+		assert.True(t, proxies[3].Off)
+	})
+
+	t.Run("fails without entries", func(t *testing.T) {
+		t.Parallel()
+
+		proxies, err := goproxy.ParseList(" , |")
+		require.ErrorIs(t, err, goproxy.ErrNoProxies)
+		assert.Nil(t, proxies)
+	})
+
+	t.Run("fails with invalid URL", func(t *testing.T) {
+		t.Parallel()
+
+		proxies, err := goproxy.ParseList("https://proxy.example.com/%zz")
+		require.ErrorIs(t, err, goproxy.ErrInvalidProxy)
+		assert.Nil(t, proxies)
+	})
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata/proxy")))
+	t.Cleanup(srv.Close)
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"GOPROXY=" + srv.URL}, []string{})
+
+	c, err := goproxy.New(cfg)
+	require.NoError(t, err)
+
+	t.Run("Info", func(t *testing.T) {
+		t.Parallel()
+
+		info, err := c.Info(context.Background(), "example.com/tool", "v1.1.0")
+		require.NoError(t, err)
+		assert.Equal(t, "v1.1.0", info.Version)
+		assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), info.Time)
+	})
+
+	t.Run("Latest", func(t *testing.T) {
+		t.Parallel()
+
+		info, err := c.Latest(context.Background(), "example.com/tool")
+		require.NoError(t, err)
+		assert.Equal(t, "v1.1.0", info.Version)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+
+		vers, err := c.List(context.Background(), "github.com/BurntSushi/toml")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v1.3.2", "v1.4.0"}, vers)
+	})
+
+	t.Run("Retractions", func(t *testing.T) {
+		t.Parallel()
+
+		rs, err := c.Retractions(context.Background(), "example.com/tool", "v1.1.0")
+		require.NoError(t, err)
+		require.Len(t, rs, 2)
+
+		assert.Equal(t, "v1.0.1", rs[0].Low.Original())
+		assert.Equal(t, "v1.0.1", rs[0].High.Original())
+		assert.Equal(t, "Published with a broken build.", rs[0].Rationale)
+
+		assert.Equal(t, "v0.1.0", rs[1].Low.Original())
+		assert.Equal(t, "v0.9.9", rs[1].High.Original())
+		assert.Empty(t, rs[1].Rationale)
+	})
+
+	t.Run("List not found", func(t *testing.T) {
+		t.Parallel()
+
+		vers, err := c.List(context.Background(), "example.com/missing")
+		require.ErrorIs(t, err, goproxy.ErrNotFound)
+		assert.Nil(t, vers)
+	})
+
+	t.Run("ModuleRoot", func(t *testing.T) {
+		t.Parallel()
+
+		mod, err := c.ModuleRoot(context.Background(), "example.com/tool/cmd/tool")
+		require.NoError(t, err)
+		assert.Equal(t, "example.com/tool", mod)
+	})
+
+	t.Run("ModuleRoot not found", func(t *testing.T) {
+		t.Parallel()
+
+		mod, err := c.ModuleRoot(context.Background(), "example.com/missing/cmd/missing")
+		require.ErrorIs(t, err, goproxy.ErrNotFound)
+		assert.Empty(t, mod)
+	})
+}
+
+func TestVersions(t *testing.T) {
+	t.Parallel()
+
+	fixture := httptest.NewServer(http.FileServer(http.Dir("testdata/proxy")))
+	t.Cleanup(fixture.Close)
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(missing.Close)
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(broken.Close)
+
+	const toolVers = "v1.0.0 v1.0.1 v1.1.0 v1.2.0-rc.1"
+
+	tests := map[string]struct {
+		environ []string
+		pkg     string
+		expVers string
+		expErr  error
+	}{
+		"pass with module path": {
+			environ: []string{"GOPROXY=" + fixture.URL},
+			pkg:     "example.com/tool",
+			expVers: toolVers,
+		},
+		"pass with package path": {
+			environ: []string{"GOPROXY=" + fixture.URL},
+			pkg:     "example.com/tool/cmd/tool",
+			expVers: toolVers,
+		},
+		"pass with case-encoded path": {
+			environ: []string{"GOPROXY=" + fixture.URL},
+			pkg:     "github.com/BurntSushi/toml/cmd/tomlv",
+			expVers: "v1.3.2 v1.4.0",
+		},
+		"pass with only pseudo-versions": {
+			environ: []string{"GOPROXY=" + fixture.URL},
+			pkg:     "example.com/pseudo",
+			expVers: "v0.0.0-20240102030405-abcdefabcdef",
+		},
+		"pass with incompatible versions": {
+			environ: []string{"GOPROXY=" + fixture.URL},
+			pkg:     "example.com/legacy/cmd/legacy",
+			expVers: "v1.5.0 v2.0.0+incompatible v17.3.1+incompatible",
+		},
+		"pass with newer major versions": {
+			environ: []string{"GOPROXY=" + fixture.URL},
+			pkg:     "example.com/multi/cmd/multi",
+			expVers: "v1.0.0 v1.1.0 v2.0.0 v2.1.0 v3.0.0-rc.1",
+		},
+		"pass with major version suffix": {
+			environ: []string{"GOPROXY=" + fixture.URL},
+			pkg:     "example.com/multi/v2/cmd/multi",
+			expVers: "v2.0.0 v2.1.0 v3.0.0-rc.1",
+		},
+		"pass with comma fallback after not found": {
+			environ: []string{"GOPROXY=" + missing.URL + "," + fixture.URL},
+			pkg:     "example.com/tool",
+			expVers: toolVers,
+		},
+		"pass with pipe fallback after error": {
+			environ: []string{"GOPROXY=" + broken.URL + "|" + fixture.URL},
+			pkg:     "example.com/tool",
+			expVers: toolVers,
+		},
+		"fail without comma fallback after error": {
+			environ: []string{"GOPROXY=" + broken.URL + "," + fixture.URL},
+			pkg:     "example.com/tool",
+			expErr:  goproxy.ErrUnexpectedStatus,
+		},
+		"fail when not found": {
+			environ: []string{"GOPROXY=" + fixture.URL},
+			pkg:     "example.com/missing/cmd/missing",
+			expErr:  goproxy.ErrNotFound,
+		},
+		"fail when off": {
+			environ: []string{"GOPROXY=off"},
+			pkg:     "example.com/tool",
+			expErr:  goproxy.ErrProxyOff,
+		},
+		"fail when direct": {
+			environ: []string{"GOPROXY=" + missing.URL + ",direct"},
+			pkg:     "example.com/tool",
+			expErr:  goproxy.ErrDirect,
+		},
+		"fail when matching GONOPROXY": {
+			environ: []string{"GOPROXY=" + fixture.URL, "GONOPROXY=example.com"},
+			pkg:     "example.com/tool",
+			expErr:  goproxy.ErrDirect,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, _ := configtest.NewConfig(t, test.environ, []string{})
+
+			vers, err := goproxy.Versions(context.Background(), cfg, test.pkg)
+			require.ErrorIs(t, err, test.expErr)
+
+			if err != nil {
+				assert.Nil(t, vers)
+
+				return
+			}
+
+			assert.Equal(t, test.expVers, vers.String())
+		})
+	}
+}
+
+func TestVersions_Modules(t *testing.T) {
+	t.Parallel()
+
+	fixture := httptest.NewServer(http.FileServer(http.Dir("testdata/proxy")))
+	t.Cleanup(fixture.Close)
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"GOPROXY=" + fixture.URL}, []string{})
+
+	vers, err := goproxy.Versions(context.Background(), cfg, "example.com/multi/cmd/multi")
+	require.NoError(t, err)
+
+	exp := map[string]string{
+		"v1.0.0":      "example.com/multi",
+		"v1.1.0":      "example.com/multi",
+		"v2.0.0":      "example.com/multi/v2",
+		"v2.1.0":      "example.com/multi/v2",
+		"v3.0.0-rc.1": "example.com/multi/v3",
+	}
+
+	act := map[string]string{}
+	for _, ver := range vers.All() {
+		act[ver.Original()] = vers.Module(ver)
+	}
+
+	assert.Equal(t, exp, act)
+}
+
+func TestVersions_Retractions(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata/proxy")))
+	t.Cleanup(srv.Close)
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"GOPROXY=" + srv.URL}, []string{})
+
+	vers, err := goproxy.Versions(context.Background(), cfg, "example.com/tool")
+	require.NoError(t, err)
+
+	var retracted []string
+
+	for _, ver := range vers.All() {
+		if vers.IsRetracted(ver) {
+			retracted = append(retracted, ver.Original())
+		}
+	}
+
+	assert.Equal(t, []string{"v1.0.1"}, retracted)
+
+	latest, err := vers.LatestStable()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", latest.Original())
+}
+
+func TestVersions_Context(t *testing.T) {
+	t.Parallel()
+
+	hung := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-hung:
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(hung) })
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := configtest.NewConfig(t, []string{"GOPROXY=" + srv.URL}, []string{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		vers, err := goproxy.Versions(ctx, cfg, "example.com/tool")
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, vers)
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := configtest.NewConfig(t, []string{"GOPROXY=" + srv.URL}, []string{})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		t.Cleanup(cancel)
+
+		vers, err := goproxy.Versions(ctx, cfg, "example.com/tool")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Nil(t, vers)
+	})
+
+	t.Run("request timeout", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := configtest.NewConfig(t, []string{
+			"GOPROXY=" + srv.URL,
+			"AGI_REQUEST_TIMEOUT=50ms",
+		}, []string{})
+
+		vers, err := goproxy.Versions(context.Background(), cfg, "example.com/tool")
+		require.Error(t, err)
+		assert.Nil(t, vers)
+	})
+}
+
+func TestVersions_Offline(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request in offline mode: %s", r.URL)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"GOPROXY=" + srv.URL, "AGI_OFFLINE=true"}, []string{})
+
+	vers, err := goproxy.Versions(context.Background(), cfg, "example.com/tool")
+	require.ErrorIs(t, err, transport.ErrOffline)
+	assert.Nil(t, vers)
+}
@@ -0,0 +1,8 @@
+module example.com/tool
+
+go 1.21
+
+retract (
+	v1.0.1 // Published with a broken build.
+	[v0.1.0, v0.9.9]
+)
@@ -0,0 +1,126 @@
+package gover
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/lmittmann/tint"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+)
+
+// Source is a named Collector that can be composed with other sources
+// using Fallback or Merge.
+type Source struct {
+	Name      string
+	Collector Collector
+}
+
+// Fallback creates a Collector that tries each Source in order and
+// returns the versions from the first Source that succeeds with at
+// least one version.  Each version in the returned Collection is
+// attributed to that Source.
+//
+// If no Source returns any versions, the errors from each failed Source
+// are joined and returned as SourceErrors.  An empty Collection is only
+// returned when every Source succeeds without finding any versions.
+func Fallback(srcs ...Source) Collector {
+	return func(ctx context.Context, cfg *config.Config, pkg string) (*Collection, error) {
+		if len(srcs) == 0 {
+			return nil, ErrNoSources
+		}
+
+		var errs []error
+
+		for _, src := range srcs {
+			// A canceled collection shouldn't fall through to the
+			// remaining sources.
+			if err := ctx.Err(); err != nil {
+				return nil, errors.Join(append(errs, err)...)
+			}
+
+			col, err := collect(ctx, cfg, src, pkg)
+			if err != nil {
+				errs = append(errs, err)
+
+				continue
+			}
+
+			if col.Len() > 0 {
+				return col, nil
+			}
+		}
+
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+
+		return NewCollection(), nil
+	}
+}
+
+// Merge creates a Collector that calls every Source and returns the
+// union of their versions.  When more than one Source provides the same
+// version, it's attributed to the Source that's listed first.
+//
+// The errors from each failed Source are joined and returned as
+// SourceErrors.  Unlike most Collectors, the returned Collection is not
+// nil when at least one Source succeeds, even if other Sources failed,
+// so that callers can decide whether the partial result is acceptable.
+func Merge(srcs ...Source) Collector {
+	return func(ctx context.Context, cfg *config.Config, pkg string) (*Collection, error) {
+		if len(srcs) == 0 {
+			return nil, ErrNoSources
+		}
+
+		var (
+			cols []*Collection
+			errs []error
+		)
+
+		for _, src := range srcs {
+			// A canceled collection shouldn't query the remaining
+			// sources.
+			if err := ctx.Err(); err != nil {
+				return nil, errors.Join(append(errs, err)...)
+			}
+
+			col, err := collect(ctx, cfg, src, pkg)
+			if err != nil {
+				errs = append(errs, err)
+
+				continue
+			}
+
+			cols = append(cols, col)
+		}
+
+		if len(cols) == 0 {
+			return nil, errors.Join(errs...)
+		}
+
+		return merge(cols...), errors.Join(errs...)
+	}
+}
+
+func collect(ctx context.Context, cfg *config.Config, src Source, pkg string) (*Collection, error) {
+	log := cfg.Log().With(
+		slog.String("source", src.Name),
+		slog.String("package", pkg),
+	)
+
+	col, err := src.Collector(ctx, cfg, pkg)
+	if err != nil {
+		log.Warn("Failed to collect versions", tint.Err(err))
+
+		return nil, &SourceError{
+			Source: src.Name,
+			Err:    err,
+		}
+	}
+
+	log.Debug("Collected versions", slog.Int("count", col.Len()))
+
+	return col.WithSource(src.Name), nil
+}
@@ -0,0 +1,211 @@
+package gover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/gover/govertest"
+)
+
+var errSourceFailed = errors.New("source failed")
+
+func TestFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		srcs      []gover.Source
+		expVers   string
+		expSrc    string
+		expErr    error
+		expSrcErr []string
+	}{
+		"first source succeeds": {
+			srcs:    []gover.Source{source(t, "proxy", "v1.0.0 v1.1.0"), source(t, "git", "v1.2.0")},
+			expVers: "v1.0.0 v1.1.0",
+			expSrc:  "proxy",
+		},
+		"falls back after error": {
+			srcs:    []gover.Source{failing("proxy"), source(t, "git", "v1.2.0")},
+			expVers: "v1.2.0",
+			expSrc:  "git",
+		},
+		"falls back after empty collection": {
+			srcs:    []gover.Source{source(t, "proxy", ""), source(t, "git", "v1.2.0")},
+			expVers: "v1.2.0",
+			expSrc:  "git",
+		},
+		"reports every failed source": {
+			srcs:      []gover.Source{failing("proxy"), source(t, "git", ""), failing("pkgsite")},
+			expErr:    errSourceFailed,
+			expSrcErr: []string{"proxy", "pkgsite"},
+		},
+		"succeeds with empty collection": {
+			srcs:    []gover.Source{source(t, "proxy", ""), source(t, "git", "")},
+			expVers: "",
+		},
+		"fails without sources": {
+			srcs:   nil,
+			expErr: gover.ErrNoSources,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+			col, err := gover.Fallback(test.srcs...)(context.Background(), cfg, "example.com/tool")
+			require.ErrorIs(t, err, test.expErr)
+			assert.Equal(t, test.expSrcErr, sourceErrors(err))
+
+			if err != nil {
+				assert.Nil(t, col)
+
+				return
+			}
+
+			assert.Equal(t, test.expVers, col.String())
+
+			for _, ver := range col.All() {
+				assert.Equal(t, test.expSrc, col.Source(ver))
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unions all sources", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+		col, err := gover.Merge(
+			source(t, "proxy", "v1.0.0 v1.1.0"),
+			source(t, "git", "v1.1.0 v1.2.0-rc.1"),
+			source(t, "pkgsite", "v0.9.0 v1.0.0"),
+		)(context.Background(), cfg, "example.com/tool")
+		require.NoError(t, err)
+		assert.Equal(t, "v0.9.0 v1.0.0 v1.1.0 v1.2.0-rc.1", col.String())
+
+		exp := map[string]string{
+			"v0.9.0":      "pkgsite",
+			"v1.0.0":      "proxy",
+			"v1.1.0":      "proxy",
+			"v1.2.0-rc.1": "git",
+		}
+
+		for _, ver := range col.All() {
+			assert.Equal(t, exp[ver.Original()], col.Source(ver), ver.Original())
+		}
+	})
+
+	t.Run("returns partial result with errors", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+		col, err := gover.Merge(
+			failing("proxy"),
+			source(t, "git", "v1.1.0"),
+		)(context.Background(), cfg, "example.com/tool")
+		require.ErrorIs(t, err, errSourceFailed)
+		assert.Equal(t, []string{"proxy"}, sourceErrors(err))
+		require.NotNil(t, col)
+		assert.Equal(t, "v1.1.0", col.String())
+	})
+
+	t.Run("fails when all sources fail", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+		col, err := gover.Merge(failing("proxy"), failing("git"))(context.Background(), cfg, "example.com/tool")
+		require.ErrorIs(t, err, errSourceFailed)
+		assert.Equal(t, []string{"proxy", "git"}, sourceErrors(err))
+		assert.Nil(t, col)
+	})
+}
+
+func TestFallback_Canceled(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var called []string
+
+	cancelling := gover.Source{
+		Name: "proxy",
+		Collector: func(ctx context.Context, _ *config.Config, _ string) (*gover.Collection, error) {
+			called = append(called, "proxy")
+			cancel()
+
+			return nil, ctx.Err()
+		},
+	}
+
+	next := gover.Source{
+		Name: "git",
+		Collector: func(context.Context, *config.Config, string) (*gover.Collection, error) {
+			called = append(called, "git")
+
+			return gover.NewCollection(), nil
+		},
+	}
+
+	col, err := gover.Fallback(cancelling, next)(ctx, cfg, "example.com/tool")
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, col)
+	assert.Equal(t, []string{"proxy"}, called)
+}
+
+func source(t *testing.T, name string, vers string) gover.Source {
+	t.Helper()
+
+	col := govertest.NewCollection(t, vers)
+
+	return gover.Source{
+		Name: name,
+		Collector: func(context.Context, *config.Config, string) (*gover.Collection, error) {
+			return col, nil
+		},
+	}
+}
+
+func failing(name string) gover.Source {
+	return gover.Source{
+		Name: name,
+		Collector: func(context.Context, *config.Config, string) (*gover.Collection, error) {
+			return nil, errSourceFailed
+		},
+	}
+}
+
+func sourceErrors(err error) []string {
+	var srcs []string
+
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) {
+		return nil
+	}
+
+	for _, err := range joined.Unwrap() {
+		var srcErr *gover.SourceError
+		if errors.As(err, &srcErr) {
+			srcs = append(srcs, srcErr.Source)
+		}
+	}
+
+	return srcs
+}
@@ -3,13 +3,51 @@ package gover
 import "errors"
 
 // ErrContainsBuildMetadata is returned when a valid semantic version
-// contains build metadata and therefore can't be parsed as a Go version.
+// contains build metadata other than +incompatible and therefore can't
+// be parsed as a Go version.
 var ErrContainsBuildMetadata = errors.New("version contains build metadata")
 
+// ErrInvalidIncompatible is returned when a version with the
+// +incompatible suffix has a major version lower than 2.
+var ErrInvalidIncompatible = errors.New("+incompatible requires a major version of 2 or higher")
+
+// ErrInvalidPseudoVersion is returned when a version can't be decoded
+// as a Go pseudo-version or when a pseudo-version can't be created from
+// the provided revision.
+var ErrInvalidPseudoVersion = errors.New("invalid Go pseudo-version")
+
+// ErrInvalidQuery is returned when a version query can't be parsed.
+var ErrInvalidQuery = errors.New("invalid version query")
+
 // ErrMissingLeadingV is returned when an otherwise valid semantic version
 // is missing the leading "v" required by Go versions.
 var ErrMissingLeadingV = errors.New("version is missing leading \"v\"")
 
+// ErrNoMatchingVersion is returned when no version in a Collection
+// matches a version query.
+var ErrNoMatchingVersion = errors.New("no matching versions for query")
+
 // ErrNoStableVersion is returned when a Collection contains only
 // pre-release versions (including pseudo-versions.)
 var ErrNoStableVersion = errors.New("no stable Go versions were found in the collection")
+
+// ErrNoSources is returned when a composed Collector is created without
+// any Sources.
+var ErrNoSources = errors.New("no version sources were provided")
+
+// SourceError is returned when one of the Sources in a composed
+// Collector fails to collect versions.
+type SourceError struct {
+	Source string
+	Err    error
+}
+
+// Error implements error.
+func (e *SourceError) Error() string {
+	return "version source " + e.Source + " failed: " + e.Err.Error()
+}
+
+// Unwrap returns the error returned by the Source's Collector.
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,134 @@
+package gover
+
+import (
+	"iter"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Predicate reports whether a Go module version number should be kept
+// by Collection.Filter.  The functions IsRelease, IsPrerelease,
+// IsTaggedPrerelease, IsPseudoVersion and IsIncompatible, as well as the
+// method value Collection.IsRetracted, can all be used as a Predicate.
+type Predicate func(ver *semver.Version) bool
+
+// InRange returns a Predicate that matches the Go module version
+// numbers between low and high (inclusive.)  Either bound may be nil to
+// leave that end of the range open.
+func InRange(low, high *semver.Version) Predicate {
+	return func(ver *semver.Version) bool {
+		return (low == nil || Compare(low, ver) <= 0) && (high == nil || Compare(ver, high) <= 0)
+	}
+}
+
+// Not returns a Predicate that matches the Go module version numbers
+// that aren't matched by the provided Predicate.
+func Not(pred Predicate) Predicate {
+	return func(ver *semver.Version) bool {
+		return !pred(ver)
+	}
+}
+
+// Ascending returns an iterator over the Collection's Go module version
+// numbers starting with the lowest version.
+func (c *Collection) Ascending() iter.Seq[*semver.Version] {
+	return slices.Values(c.col)
+}
+
+// Descending returns an iterator over the Collection's Go module version
+// numbers starting with the most recent version.
+func (c *Collection) Descending() iter.Seq[*semver.Version] {
+	return func(yield func(*semver.Version) bool) {
+		for i := len(c.col) - 1; i >= 0; i-- {
+			if !yield(c.col[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Between creates a clone of the Collection containing only the Go
+// module version numbers between low and high (inclusive.)
+func (c *Collection) Between(low, high *semver.Version) *Collection {
+	return c.Filter(InRange(low, high))
+}
+
+// Difference creates a clone of the Collection without the Go module
+// version numbers that are present in any of the other Collections.
+func (c *Collection) Difference(others ...*Collection) *Collection {
+	return c.Filter(func(ver *semver.Version) bool {
+		return !slices.ContainsFunc(others, func(o *Collection) bool { return o.contains(ver) })
+	})
+}
+
+// Filter creates a clone of the Collection containing only the Go
+// module version numbers that match all the provided Predicates.
+func (c *Collection) Filter(preds ...Predicate) *Collection {
+	clone := c.clone()
+	clone.col = slices.DeleteFunc(clone.col, Not(all(preds...)))
+
+	return clone
+}
+
+// GroupByMajor splits the Collection into a Collection per major
+// version.  Versions with the +incompatible suffix are grouped with the
+// compatible versions that have the same major version.
+func (c *Collection) GroupByMajor() map[uint64]*Collection {
+	groups := map[uint64]*Collection{}
+
+	for ver := range c.Ascending() {
+		if _, ok := groups[ver.Major()]; !ok {
+			groups[ver.Major()] = c.Filter(func(v *semver.Version) bool { return v.Major() == ver.Major() })
+		}
+	}
+
+	return groups
+}
+
+// Intersect creates a clone of the Collection containing only the Go
+// module version numbers that are also present in all the other
+// Collections.
+func (c *Collection) Intersect(others ...*Collection) *Collection {
+	return c.Filter(func(ver *semver.Version) bool {
+		return !slices.ContainsFunc(others, func(o *Collection) bool { return !o.contains(ver) })
+	})
+}
+
+// LatestPatches creates a clone of the Collection containing only the
+// most recent Go module version number for each major and minor
+// version.  Filter the Collection first (e.g. with IsRelease) to choose
+// which versions are considered.
+func (c *Collection) LatestPatches() *Collection {
+	type minor struct{ major, minor uint64 }
+
+	latest := map[minor]*semver.Version{}
+
+	for ver := range c.Ascending() {
+		latest[minor{ver.Major(), ver.Minor()}] = ver
+	}
+
+	return c.Filter(func(ver *semver.Version) bool {
+		return latest[minor{ver.Major(), ver.Minor()}] == ver
+	})
+}
+
+// all returns a Predicate that matches the Go module version numbers
+// that are matched by every provided Predicate.
+func all(preds ...Predicate) Predicate {
+	return func(ver *semver.Version) bool {
+		for _, pred := range preds {
+			if !pred(ver) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// contains returns a boolean value indicating whether the Collection
+// includes the Go module version number.
+func (c *Collection) contains(ver *semver.Version) bool {
+	return slices.ContainsFunc(c.col, func(v *semver.Version) bool { return v.Original() == ver.Original() })
+}
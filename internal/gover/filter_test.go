@@ -0,0 +1,188 @@
+package gover_test
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/gover/govertest"
+)
+
+const filterVers = "v0.9.0 v1.0.0 v1.0.1 v1.1.0-rc.1 v1.1.0 v1.1.1 v1.1.2-0.20170915032832-14c0d48ead0c v2.0.0+incompatible v2.0.0 v2.1.0-beta.1"
+
+func TestCollection_Filter(t *testing.T) {
+	t.Parallel()
+
+	retracted := gover.Retraction{
+		Low:  govertest.NewVersion(t, "v1.0.0"),
+		High: govertest.NewVersion(t, "v1.0.1"),
+	}
+
+	col := govertest.NewCollection(t, filterVers).WithRetractions(retracted)
+
+	tests := map[string]struct {
+		preds []gover.Predicate
+		exp   string
+	}{
+		"without predicates": {
+			exp: filterVers,
+		},
+		"releases": {
+			preds: []gover.Predicate{gover.IsRelease},
+			exp:   "v0.9.0 v1.0.0 v1.0.1 v1.1.0 v1.1.1 v2.0.0+incompatible v2.0.0",
+		},
+		"pre-releases without pseudo-versions": {
+			preds: []gover.Predicate{gover.IsTaggedPrerelease},
+			exp:   "v1.1.0-rc.1 v2.1.0-beta.1",
+		},
+		"pseudo-versions": {
+			preds: []gover.Predicate{gover.IsPseudoVersion},
+			exp:   "v1.1.2-0.20170915032832-14c0d48ead0c",
+		},
+		"releases that aren't retracted": {
+			preds: []gover.Predicate{gover.IsRelease, gover.Not(col.IsRetracted)},
+			exp:   "v0.9.0 v1.1.0 v1.1.1 v2.0.0+incompatible v2.0.0",
+		},
+		"incompatible": {
+			preds: []gover.Predicate{gover.IsIncompatible},
+			exp:   "v2.0.0+incompatible",
+		},
+		"open range": {
+			preds: []gover.Predicate{gover.InRange(govertest.NewVersion(t, "v1.1.1"), nil)},
+			exp:   "v1.1.1 v1.1.2-0.20170915032832-14c0d48ead0c v2.0.0+incompatible v2.0.0 v2.1.0-beta.1",
+		},
+		"no matches": {
+			preds: []gover.Predicate{gover.IsRelease, gover.IsPrerelease},
+			exp:   "",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			act := col.Filter(test.preds...)
+			assert.Equal(t, test.exp, act.String())
+			assert.Equal(t, col.Retractions(), act.Retractions())
+			assert.Equal(t, filterVers, col.String())
+		})
+	}
+}
+
+func TestCollection_Between(t *testing.T) {
+	t.Parallel()
+
+	col := govertest.NewCollection(t, filterVers)
+
+	act := col.Between(govertest.NewVersion(t, "v1.0.1"), govertest.NewVersion(t, "v1.1.1"))
+	assert.Equal(t, "v1.0.1 v1.1.0-rc.1 v1.1.0 v1.1.1", act.String())
+}
+
+func TestCollection_LatestPatches(t *testing.T) {
+	t.Parallel()
+
+	col := govertest.NewCollection(t, filterVers)
+
+	assert.Equal(t, "v0.9.0 v1.0.1 v1.1.2-0.20170915032832-14c0d48ead0c v2.0.0 v2.1.0-beta.1", col.LatestPatches().String())
+	assert.Equal(t, "v0.9.0 v1.0.1 v1.1.1 v2.0.0", col.Filter(gover.IsRelease).LatestPatches().String())
+}
+
+func TestCollection_GroupByMajor(t *testing.T) {
+	t.Parallel()
+
+	groups := govertest.NewCollection(t, filterVers).WithSource("proxy").GroupByMajor()
+	assert.Equal(t, []uint64{0, 1, 2}, slices.Sorted(maps.Keys(groups)))
+
+	exp := map[uint64]string{
+		0: "v0.9.0",
+		1: "v1.0.0 v1.0.1 v1.1.0-rc.1 v1.1.0 v1.1.1 v1.1.2-0.20170915032832-14c0d48ead0c",
+		2: "v2.0.0+incompatible v2.0.0 v2.1.0-beta.1",
+	}
+
+	for major, group := range groups {
+		assert.Equal(t, exp[major], group.String())
+
+		for ver := range group.Ascending() {
+			assert.Equal(t, "proxy", group.Source(ver))
+		}
+	}
+}
+
+func TestCollection_Iterators(t *testing.T) {
+	t.Parallel()
+
+	col := govertest.NewCollection(t, "v1.0.0 v1.1.0 v1.2.0")
+
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0", "v1.2.0"}, originals(col.Ascending()))
+	assert.Equal(t, []string{"v1.2.0", "v1.1.0", "v1.0.0"}, originals(col.Descending()))
+
+	for ver := range col.Descending() {
+		assert.Equal(t, "v1.2.0", ver.Original())
+
+		break
+	}
+}
+
+func TestCollection_SetOperations(t *testing.T) {
+	t.Parallel()
+
+	a := govertest.NewCollection(t, "v1.0.0 v1.1.0 v1.2.0").WithSource("a")
+	b := govertest.NewCollection(t, "v1.1.0 v1.2.0 v1.3.0").WithSource("b")
+	c := govertest.NewCollection(t, "v1.2.0 v1.4.0")
+
+	tests := map[string]struct {
+		act       *gover.Collection
+		exp       string
+		expSource string
+	}{
+		"union": {
+			act:       a.Union(b, c),
+			exp:       "v1.0.0 v1.1.0 v1.2.0 v1.3.0 v1.4.0",
+			expSource: "a",
+		},
+		"intersect": {
+			act:       a.Intersect(b, c),
+			exp:       "v1.2.0",
+			expSource: "a",
+		},
+		"intersect without others": {
+			act:       a.Intersect(),
+			exp:       "v1.0.0 v1.1.0 v1.2.0",
+			expSource: "a",
+		},
+		"difference": {
+			act:       b.Difference(a),
+			exp:       "v1.3.0",
+			expSource: "b",
+		},
+		"difference with several others": {
+			act:       a.Difference(b, c),
+			exp:       "v1.0.0",
+			expSource: "a",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.exp, test.act.String())
+			assert.Equal(t, test.expSource, test.act.Source(test.act.All()[0]))
+		})
+	}
+}
+
+func originals(vers iter.Seq[*semver.Version]) []string {
+	var strs []string
+
+	for ver := range vers {
+		strs = append(strs, ver.Original())
+	}
+
+	return strs
+}
@@ -3,10 +3,10 @@
 package gover
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"slices"
-	"sort"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -18,9 +18,18 @@ const (
 	// GoVersionPrefix is the prefix required for Go version strings.
 	GoVersionPrefix = "v"
 
-	// PseudoVersionRegexp is a pattern that matches the suffix present
-	// on Go pseudo-versions.
-	PseudoVersionRegexp = "^[0-9]{14}-[0-9a-f]{12}$"
+	// IncompatibleMetadata is the only build metadata allowed in a Go
+	// version string.  It marks versions of modules with major version
+	// 2 or higher that were tagged before the repository adopted Go
+	// modules.
+	IncompatibleMetadata = "incompatible"
+
+	// PseudoVersionRegexp is a pattern that matches the pre-release
+	// suffix present on each of the three forms of Go pseudo-versions.
+	// The sub-matches are the base version's pre-release suffix (if
+	// any,) the "0." that follows a base version (if any,) the commit
+	// timestamp and the revision.
+	PseudoVersionRegexp = `^(?:(.+\.)?(0\.))?([0-9]{14})-([0-9a-f]{12})$`
 )
 
 var pseudoVersionRegexp = regexp.MustCompile(PseudoVersionRegexp)
@@ -36,7 +45,9 @@ var pseudoVersionRegexp = regexp.MustCompile(PseudoVersionRegexp)
 //     module version number.
 //
 //  2. Build metadata, which is appended after a "+" in a semantic version
-//     number, is not allowed in a Go version number.
+//     number, is not allowed in a Go version number except for the
+//     "+incompatible" suffix on versions with a major version of 2 or
+//     higher.
 //
 //  3. A Go pseudo-version number is a specialization of a semantic
 //     version with a carefully formatted pre-release suffix.
@@ -56,8 +67,16 @@ func NewVersion(v string) (*semver.Version, error) {
 		return nil, err
 	}
 
-	// A Go version must not contain build metadata.
-	if ver.Metadata() != "" {
+	// A Go version must not contain build metadata other than the
+	// +incompatible suffix, which requires a major version of at least
+	// two.
+	switch ver.Metadata() {
+	case "":
+	case IncompatibleMetadata:
+		if ver.Major() < 2 {
+			return nil, fmt.Errorf("%w: parsed %s", ErrInvalidIncompatible, v)
+		}
+	default:
 		return nil, fmt.Errorf("%w: parsed %s", ErrContainsBuildMetadata, v)
 	}
 
@@ -66,6 +85,12 @@ func NewVersion(v string) (*semver.Version, error) {
 	return semver.NewVersion(v)
 }
 
+// IsIncompatible returns a boolean value indicating whether the Go
+// version has the +incompatible suffix.
+func IsIncompatible(v *semver.Version) bool {
+	return v.Metadata() == IncompatibleMetadata
+}
+
 // IsPrerelease returns a boolean value indicating whether the Go
 // version has a pre-release suffix
 func IsPrerelease(v *semver.Version) bool {
@@ -75,8 +100,20 @@ func IsPrerelease(v *semver.Version) bool {
 // IsPseudoVersion returns a boolean value indicating whether the
 // Go version has a pre-release suffix that's formatted as
 // a pseudo-version.
+//
+// Use ParsePseudoVersion to decode the pseudo-version's base version,
+// commit timestamp and revision.
 func IsPseudoVersion(v *semver.Version) bool {
-	return pseudoVersionRegexp.Match([]byte(v.Prerelease()))
+	_, err := ParsePseudoVersion(v)
+
+	return err == nil
+}
+
+// IsTaggedPrerelease returns a boolean value indicating whether the Go
+// version has a pre-release suffix that isn't formatted as a
+// pseudo-version (e.g. v1.2.0-rc.1.)
+func IsTaggedPrerelease(v *semver.Version) bool {
+	return IsPrerelease(v) && !IsPseudoVersion(v)
 }
 
 // IsRelease returns a boolean value indicating whether the Go version
@@ -85,18 +122,52 @@ func IsRelease(v *semver.Version) bool {
 	return !IsPrerelease(v)
 }
 
+// Compare returns an integer comparing two Go module version numbers
+// using semantic version precedence.
+//
+// Semantic version precedence ignores build metadata, so a version with
+// the +incompatible suffix is ordered before the otherwise identical
+// compatible version.  Since the two can only both exist when a module
+// has been migrated to a major version suffix, the compatible version
+// is the more recent of the pair.
+func Compare(a, b *semver.Version) int {
+	if c := a.Compare(b); c != 0 {
+		return c
+	}
+
+	switch {
+	case IsIncompatible(a) && !IsIncompatible(b):
+		return -1
+	case !IsIncompatible(a) && IsIncompatible(b):
+		return 1
+	default:
+		return 0
+	}
+}
+
 // Collection stores a sorted collection of Go module version numbers.
 type Collection struct {
-	col semver.Collection
+	col         semver.Collection
+	meta        map[string]meta
+	retractions []Retraction
+}
+
+// meta stores the information that's known about a single Go module
+// version number in a Collection.  Entries are keyed by the version's
+// Original() string.
+type meta struct {
+	module string
+	source string
 }
 
 // NewCollection sorts and stores the provided Go module version numbers.
 func NewCollection(vers ...*semver.Version) *Collection {
 	col := Collection{
-		col: semver.Collection(vers),
+		col:  semver.Collection(vers),
+		meta: make(map[string]meta, len(vers)),
 	}
 
-	sort.Sort(col.col)
+	slices.SortStableFunc(col.col, Compare)
 
 	return &col
 }
@@ -107,18 +178,14 @@ func (c *Collection) All() []*semver.Version {
 }
 
 // LatestStable returns the Go module version number for the most recent
-// released version of the module.
+// released version of the module.  Versions that have been retracted by
+// the module's author are skipped.
 //
 // If there is no release version in the collection, an ErrNoStableVersion
 // error is returned.
 func (c *Collection) LatestStable() (*semver.Version, error) {
-	var col = semver.Collection(make([]*semver.Version, len(c.col)))
-	copy(col, c.col)
-
-	slices.Reverse(col)
-
-	for _, ver := range col {
-		if IsRelease(ver) {
+	for ver := range c.Descending() {
+		if IsRelease(ver) && !c.IsRetracted(ver) {
 			return ver, nil
 		}
 	}
@@ -132,6 +199,58 @@ func (c *Collection) Len() int {
 	return len(c.col)
 }
 
+// Module returns the path of the Go module that published the Go module
+// version number or an empty string if the module path isn't known.
+//
+// A Collection can contain versions from more than one module when the
+// module has been migrated to a major version suffix (e.g. /v2.)
+func (c *Collection) Module(ver *semver.Version) string {
+	return c.meta[ver.Original()].module
+}
+
+// Source returns the name of the Source that provided the Go module
+// version number or an empty string if the version wasn't collected
+// through a named Source.
+func (c *Collection) Source(ver *semver.Version) string {
+	return c.meta[ver.Original()].source
+}
+
+// Union creates a Collection containing the Go module version numbers
+// and retractions from this and the other Collections.  When a Go module
+// version number is present in more than one Collection, the first
+// Collection's entry is kept.
+func (c *Collection) Union(others ...*Collection) *Collection {
+	return merge(append([]*Collection{c}, others...)...)
+}
+
+// WithModule creates a clone of the Collection where each Go module
+// version number is attributed to the Go module with the provided path.
+func (c *Collection) WithModule(path string) *Collection {
+	clone := c.clone()
+
+	for _, ver := range clone.col {
+		m := clone.meta[ver.Original()]
+		m.module = path
+		clone.meta[ver.Original()] = m
+	}
+
+	return clone
+}
+
+// WithSource creates a clone of the Collection where each Go module
+// version number is attributed to the named Source.
+func (c *Collection) WithSource(name string) *Collection {
+	clone := c.clone()
+
+	for _, ver := range clone.col {
+		m := clone.meta[ver.Original()]
+		m.source = name
+		clone.meta[ver.Original()] = m
+	}
+
+	return clone
+}
+
 // String returns a space-delimited string representation of the Go
 // module version Collection starting with the lowest version ending with
 // the most recent version.
@@ -145,4 +264,58 @@ func (c *Collection) String() string {
 	return strings.Join(vers, " ")
 }
 
-type Collector func(cfg *config.Config, pkg string) (*Collection, error)
+// clone creates a copy of the Collection that can be modified without
+// changing the original.
+func (c *Collection) clone() *Collection {
+	clone := &Collection{
+		col:         make(semver.Collection, len(c.col)),
+		meta:        make(map[string]meta, len(c.meta)),
+		retractions: slices.Clone(c.retractions),
+	}
+
+	copy(clone.col, c.col)
+
+	for k, v := range c.meta {
+		clone.meta[k] = v
+	}
+
+	return clone
+}
+
+// merge creates a Collection containing the union of the provided
+// Collections.  When a Go module version number is present in more than
+// one Collection, the first Collection's entry is kept.  The retractions
+// from every Collection are kept.
+func merge(cols ...*Collection) *Collection {
+	var (
+		vers        []*semver.Version
+		meta        = map[string]meta{}
+		retractions []Retraction
+	)
+
+	for _, col := range cols {
+		retractions = append(retractions, col.retractions...)
+
+		for _, ver := range col.col {
+			if _, ok := meta[ver.Original()]; ok {
+				continue
+			}
+
+			vers = append(vers, ver)
+			meta[ver.Original()] = col.meta[ver.Original()]
+		}
+	}
+
+	merged := NewCollection(vers...)
+	merged.meta = meta
+	merged.retractions = retractions
+
+	return merged
+}
+
+// Collector retrieves the Collection of Go module version numbers that
+// are available for the provided package.
+//
+// Implementations must stop collecting and return the context's error
+// when the provided context is canceled or its deadline is exceeded.
+type Collector func(ctx context.Context, cfg *config.Config, pkg string) (*Collection, error)
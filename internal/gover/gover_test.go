@@ -8,19 +8,21 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/gover/govertest"
 )
 
 func TestNewVersion(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
-		ver       string
-		expOrig   string
-		expStr    string
-		expErr    error
-		expPre    bool
-		expPseudo bool
-		expRel    bool
+		ver         string
+		expOrig     string
+		expStr      string
+		expErr      error
+		expPre      bool
+		expPseudo   bool
+		expRel      bool
+		expIncompat bool
 	}{
 		"pass with leading v": {
 			ver:       "v1.0.0",
@@ -49,6 +51,30 @@ func TestNewVersion(t *testing.T) {
 			expPseudo: true,
 			expRel:    false,
 		},
+		"pass with incompatible": {
+			ver:         "v2.0.0+incompatible",
+			expOrig:     "v2.0.0+incompatible",
+			expStr:      "2.0.0+incompatible",
+			expErr:      nil,
+			expPre:      false,
+			expPseudo:   false,
+			expRel:      true,
+			expIncompat: true,
+		},
+		"pass with incompatible prerelease": {
+			ver:         "v3.1.0-rc.1+incompatible",
+			expOrig:     "v3.1.0-rc.1+incompatible",
+			expStr:      "3.1.0-rc.1+incompatible",
+			expErr:      nil,
+			expPre:      true,
+			expPseudo:   false,
+			expRel:      false,
+			expIncompat: true,
+		},
+		"fail with incompatible major version 1": {
+			ver:    "v1.2.3+incompatible",
+			expErr: gover.ErrInvalidIncompatible,
+		},
 		"fail without leading v": {
 			ver:    "1.0.0",
 			expErr: gover.ErrMissingLeadingV,
@@ -79,6 +105,7 @@ func TestNewVersion(t *testing.T) {
 			assert.Equal(t, test.expPre, gover.IsPrerelease(v))
 			assert.Equal(t, test.expPseudo, gover.IsPseudoVersion(v))
 			assert.Equal(t, test.expRel, gover.IsRelease(v))
+			assert.Equal(t, test.expIncompat, gover.IsIncompatible(v))
 		})
 	}
 }
@@ -176,3 +203,67 @@ func TestSortCollection(t *testing.T) {
 		assert.Nil(t, ver)
 	})
 }
+
+func TestSortCollection_Incompatible(t *testing.T) {
+	t.Parallel()
+
+	col := gover.NewCollection(
+		govertest.NewVersion(t, "v2.0.0"),
+		govertest.NewVersion(t, "v2.1.0+incompatible"),
+		govertest.NewVersion(t, "v1.9.0"),
+		govertest.NewVersion(t, "v2.0.0+incompatible"),
+		govertest.NewVersion(t, "v3.0.0-rc.1+incompatible"),
+	)
+
+	assert.Equal(t, "v1.9.0 v2.0.0+incompatible v2.0.0 v2.1.0+incompatible v3.0.0-rc.1+incompatible", col.String())
+
+	act, err := col.LatestStable()
+	require.NoError(t, err)
+	assert.Equal(t, "v2.1.0+incompatible", act.Original())
+}
+
+func TestCollection_Union(t *testing.T) {
+	t.Parallel()
+
+	v1 := govertest.NewCollection(t, "v1.0.0 v1.1.0").WithModule("example.com/tool").WithSource("proxy")
+	v2 := govertest.NewCollection(t, "v1.1.0 v2.0.0").WithModule("example.com/tool/v2")
+
+	col := v1.Union(v2)
+	assert.Equal(t, "v1.0.0 v1.1.0 v2.0.0", col.String())
+
+	for _, ver := range col.All() {
+		switch ver.Original() {
+		case "v2.0.0":
+			assert.Equal(t, "example.com/tool/v2", col.Module(ver))
+			assert.Empty(t, col.Source(ver))
+		default:
+			assert.Equal(t, "example.com/tool", col.Module(ver))
+			assert.Equal(t, "proxy", col.Source(ver))
+		}
+	}
+
+	assert.Equal(t, "v1.0.0 v1.1.0", v1.String())
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		exp  int
+	}{
+		{a: "v1.0.0", b: "v1.0.1", exp: -1},
+		{a: "v2.0.0", b: "v1.0.1", exp: 1},
+		{a: "v2.0.0+incompatible", b: "v2.0.0", exp: -1},
+		{a: "v2.0.0", b: "v2.0.0+incompatible", exp: 1},
+		{a: "v2.0.0+incompatible", b: "v2.0.0+incompatible", exp: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.a+" "+test.b, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.exp, gover.Compare(govertest.NewVersion(t, test.a), govertest.NewVersion(t, test.b)))
+		})
+	}
+}
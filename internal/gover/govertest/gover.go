@@ -0,0 +1,39 @@
+// Package govertest creates the gover values that are commonly needed
+// during testing.
+package govertest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/gover"
+)
+
+// NewCollection creates a gover.Collection from a space-delimited list
+// of Go module version numbers, failing the test if any of them is
+// invalid.
+func NewCollection(t *testing.T, vers string) *gover.Collection {
+	t.Helper()
+
+	var col []*semver.Version
+
+	for _, str := range strings.Fields(vers) {
+		col = append(col, NewVersion(t, str))
+	}
+
+	return gover.NewCollection(col...)
+}
+
+// NewVersion parses a Go module version number, failing the test if
+// it's invalid.
+func NewVersion(t *testing.T, s string) *semver.Version {
+	t.Helper()
+
+	ver, err := gover.NewVersion(s)
+	require.NoError(t, err)
+
+	return ver
+}
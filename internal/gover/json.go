@@ -0,0 +1,106 @@
+package gover
+
+import (
+	"encoding/json"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+var (
+	_ json.Marshaler   = (*Collection)(nil)
+	_ json.Unmarshaler = (*Collection)(nil)
+)
+
+type collectionJSON struct {
+	Versions    []versionJSON    `json:"versions"`
+	Retractions []retractionJSON `json:"retractions,omitempty"`
+}
+
+type versionJSON struct {
+	Version string `json:"version"`
+	Module  string `json:"module,omitempty"`
+	Source  string `json:"source,omitempty"`
+}
+
+type retractionJSON struct {
+	Low       string `json:"low"`
+	High      string `json:"high"`
+	Rationale string `json:"rationale,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c *Collection) MarshalJSON() ([]byte, error) {
+	payload := collectionJSON{
+		Versions: make([]versionJSON, len(c.col)),
+	}
+
+	for i, ver := range c.col {
+		m := c.meta[ver.Original()]
+
+		payload.Versions[i] = versionJSON{
+			Version: ver.Original(),
+			Module:  m.module,
+			Source:  m.source,
+		}
+	}
+
+	for _, r := range c.retractions {
+		payload.Retractions = append(payload.Retractions, retractionJSON{
+			Low:       r.Low.Original(),
+			High:      r.High.Original(),
+			Rationale: r.Rationale,
+		})
+	}
+
+	return json.Marshal(payload)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Collection) UnmarshalJSON(data []byte) error {
+	var payload collectionJSON
+
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+
+	var (
+		vers  = make([]*semver.Version, len(payload.Versions))
+		metas = make(map[string]meta, len(payload.Versions))
+	)
+
+	for i, v := range payload.Versions {
+		ver, err := NewVersion(v.Version)
+		if err != nil {
+			return err
+		}
+
+		vers[i] = ver
+		metas[ver.Original()] = meta{module: v.Module, source: v.Source}
+	}
+
+	var rs []Retraction
+
+	for _, r := range payload.Retractions {
+		low, err := NewVersion(r.Low)
+		if err != nil {
+			return err
+		}
+
+		high, err := NewVersion(r.High)
+		if err != nil {
+			return err
+		}
+
+		rs = append(rs, Retraction{
+			Low:       low,
+			High:      high,
+			Rationale: r.Rationale,
+		})
+	}
+
+	*c = *NewCollection(vers...)
+	c.meta = metas
+	c.retractions = rs
+
+	return nil
+}
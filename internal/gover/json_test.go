@@ -0,0 +1,76 @@
+package gover_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/gover/govertest"
+)
+
+func TestCollection_JSON(t *testing.T) {
+	t.Parallel()
+
+	col := govertest.NewCollection(t, "v1.0.0 v1.0.1").WithModule("example.com/tool").WithSource("goproxy").
+		Union(govertest.NewCollection(t, "v2.0.0").WithModule("example.com/tool/v2")).
+		WithRetractions(gover.Retraction{
+			Low:       govertest.NewVersion(t, "v1.0.1"),
+			High:      govertest.NewVersion(t, "v1.0.1"),
+			Rationale: "Published with a broken build.",
+		})
+
+	data, err := json.Marshal(col)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"versions": [
+			{"version": "v1.0.0", "module": "example.com/tool", "source": "goproxy"},
+			{"version": "v1.0.1", "module": "example.com/tool", "source": "goproxy"},
+			{"version": "v2.0.0", "module": "example.com/tool/v2"}
+		],
+		"retractions": [
+			{"low": "v1.0.1", "high": "v1.0.1", "rationale": "Published with a broken build."}
+		]
+	}`, string(data))
+
+	var act gover.Collection
+
+	require.NoError(t, json.Unmarshal(data, &act))
+	assert.Equal(t, col.String(), act.String())
+	assert.Equal(t, col.Retractions(), act.Retractions())
+
+	for _, ver := range act.All() {
+		assert.Equal(t, col.Module(ver), act.Module(ver))
+		assert.Equal(t, col.Source(ver), act.Source(ver))
+	}
+}
+
+func TestCollection_UnmarshalJSON_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		data   string
+		expErr error
+	}{
+		"invalid version": {
+			data:   `{"versions": [{"version": "1.0.0"}]}`,
+			expErr: gover.ErrMissingLeadingV,
+		},
+		"invalid retraction": {
+			data:   `{"versions": [], "retractions": [{"low": "v1.0.0", "high": "v1.0.0+build"}]}`,
+			expErr: gover.ErrContainsBuildMetadata,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var col gover.Collection
+
+			require.ErrorIs(t, json.Unmarshal([]byte(test.data), &col), test.expErr)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package gover
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	// PseudoVersionRevisionLength is the number of characters of the
+	// commit hash included in a Go pseudo-version.
+	PseudoVersionRevisionLength = 12
+
+	// PseudoVersionTimeFormat is the layout of the UTC commit timestamp
+	// included in a Go pseudo-version.
+	PseudoVersionTimeFormat = "20060102150405"
+)
+
+// PseudoVersion is a decoded Go [pseudo-version] which identifies a
+// specific, untagged revision of a module.
+//
+// Go uses three forms of pseudo-version depending on the most recent
+// tagged version (the base version) that precedes the revision:
+//
+//  1. vX.0.0-yyyymmddhhmmss-abcdefabcdef is used when there is no base
+//     version.
+//
+//  2. vX.Y.Z-pre.0.yyyymmddhhmmss-abcdefabcdef is used when the base
+//     version is a pre-release (vX.Y.Z-pre.)
+//
+//  3. vX.Y.(Z+1)-0.yyyymmddhhmmss-abcdefabcdef is used when the base
+//     version is a release (vX.Y.Z.)
+//
+// [pseudo-version]: https://go.dev/ref/mod#pseudo-versions
+type PseudoVersion struct {
+	ver  *semver.Version
+	base *semver.Version
+	time time.Time
+	rev  string
+}
+
+// NewPseudoVersion creates the PseudoVersion that Go would assign to the
+// revision of a module that was committed at the provided time.
+//
+// The base version is the most recent tagged version that precedes the
+// revision and may be nil when there is no such version, in which case
+// the pseudo-version's major version is used.  The revision must be a
+// hexadecimal commit hash with at least twelve characters.
+func NewPseudoVersion(major uint64, base *semver.Version, t time.Time, rev string) (*PseudoVersion, error) {
+	if len(rev) < PseudoVersionRevisionLength {
+		return nil, fmt.Errorf("%w: revision %s is too short", ErrInvalidPseudoVersion, rev)
+	}
+
+	rev = strings.ToLower(rev[:PseudoVersionRevisionLength])
+	if _, err := hex.DecodeString(rev); err != nil {
+		return nil, fmt.Errorf("%w: revision %s is not hexadecimal", ErrInvalidPseudoVersion, rev)
+	}
+
+	ts := t.UTC().Format(PseudoVersionTimeFormat)
+
+	var str string
+
+	switch {
+	case base == nil:
+		str = fmt.Sprintf("v%d.0.0-%s-%s", major, ts, rev)
+	case base.Major() != major:
+		return nil, fmt.Errorf("%w: base version %s doesn't have major version %d", ErrInvalidPseudoVersion, base.Original(), major)
+	case IsPrerelease(base):
+		str = fmt.Sprintf("v%d.%d.%d-%s.0.%s-%s", base.Major(), base.Minor(), base.Patch(), base.Prerelease(), ts, rev)
+	default:
+		str = fmt.Sprintf("v%d.%d.%d-0.%s-%s", base.Major(), base.Minor(), base.Patch()+1, ts, rev)
+	}
+
+	if base != nil && IsIncompatible(base) {
+		str += "+" + IncompatibleMetadata
+	}
+
+	ver, err := NewVersion(str)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePseudoVersion(ver)
+}
+
+// ParsePseudoVersion decodes the base version, commit timestamp and
+// revision from a Go pseudo-version.
+//
+// If the version isn't a pseudo-version, an ErrInvalidPseudoVersion
+// error is returned.
+func ParsePseudoVersion(v *semver.Version) (*PseudoVersion, error) {
+	m := pseudoVersionRegexp.FindStringSubmatch(v.Prerelease())
+	if m == nil {
+		return nil, fmt.Errorf("%w: parsed %s", ErrInvalidPseudoVersion, v.Original())
+	}
+
+	pre, zero, ts, rev := m[1], m[2] != "", m[3], m[4]
+
+	t, err := time.Parse(PseudoVersionTimeFormat, ts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: parsed %s: %w", ErrInvalidPseudoVersion, v.Original(), err)
+	}
+
+	var base string
+
+	switch {
+	case !zero:
+		// vX.0.0-yyyymmddhhmmss-abcdefabcdef
+		if v.Minor() != 0 || v.Patch() != 0 {
+			return nil, fmt.Errorf("%w: parsed %s: version without base must be vX.0.0", ErrInvalidPseudoVersion, v.Original())
+		}
+	case pre != "":
+		// vX.Y.Z-pre.0.yyyymmddhhmmss-abcdefabcdef
+		base = fmt.Sprintf("v%d.%d.%d-%s", v.Major(), v.Minor(), v.Patch(), strings.TrimSuffix(pre, "."))
+	default:
+		// vX.Y.(Z+1)-0.yyyymmddhhmmss-abcdefabcdef
+		if v.Patch() == 0 {
+			return nil, fmt.Errorf("%w: parsed %s: release base requires a patch version", ErrInvalidPseudoVersion, v.Original())
+		}
+
+		base = fmt.Sprintf("v%d.%d.%d", v.Major(), v.Minor(), v.Patch()-1)
+	}
+
+	pv := &PseudoVersion{
+		ver:  v,
+		time: t,
+		rev:  rev,
+	}
+
+	if base != "" {
+		if IsIncompatible(v) {
+			base += "+" + IncompatibleMetadata
+		}
+
+		pv.base, err = NewVersion(base)
+		if err != nil {
+			return nil, fmt.Errorf("%w: parsed %s: %w", ErrInvalidPseudoVersion, v.Original(), err)
+		}
+	}
+
+	return pv, nil
+}
+
+// Base returns the most recent tagged version that precedes the
+// pseudo-version's revision or nil if there is no such version.
+func (p *PseudoVersion) Base() *semver.Version {
+	return p.base
+}
+
+// Revision returns the twelve character prefix of the revision's
+// commit hash.
+func (p *PseudoVersion) Revision() string {
+	return p.rev
+}
+
+// String returns the pseudo-version in its original form.
+func (p *PseudoVersion) String() string {
+	return p.ver.Original()
+}
+
+// Time returns the UTC timestamp of the revision's commit.
+func (p *PseudoVersion) Time() time.Time {
+	return p.time
+}
+
+// Version returns the pseudo-version as a Go module version number.
+func (p *PseudoVersion) Version() *semver.Version {
+	return p.ver
+}
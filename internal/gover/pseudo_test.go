@@ -0,0 +1,197 @@
+package gover_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/gover/govertest"
+)
+
+func TestParsePseudoVersion(t *testing.T) {
+	t.Parallel()
+
+	commitTime := time.Date(2017, 9, 15, 3, 28, 32, 0, time.UTC)
+
+	tests := map[string]struct {
+		ver     string
+		expBase string
+		expErr  error
+	}{
+		"pass without base version": {
+			ver:     "v0.0.0-20170915032832-14c0d48ead0c",
+			expBase: "",
+		},
+		"pass without base version at major version 2": {
+			ver:     "v2.0.0-20170915032832-14c0d48ead0c",
+			expBase: "",
+		},
+		"pass with pre-release base version": {
+			ver:     "v1.2.3-rc.1.0.20170915032832-14c0d48ead0c",
+			expBase: "v1.2.3-rc.1",
+		},
+		"pass with pre-release base version ending in zero": {
+			ver:     "v1.2.3-rc.0.0.20170915032832-14c0d48ead0c",
+			expBase: "v1.2.3-rc.0",
+		},
+		"pass with release base version": {
+			ver:     "v1.2.4-0.20170915032832-14c0d48ead0c",
+			expBase: "v1.2.3",
+		},
+		"pass with incompatible base version": {
+			ver:     "v2.0.1-0.20170915032832-14c0d48ead0c+incompatible",
+			expBase: "v2.0.0+incompatible",
+		},
+		"fail with release": {
+			ver:    "v1.2.3",
+			expErr: gover.ErrInvalidPseudoVersion,
+		},
+		"fail with pre-release": {
+			ver:    "v1.2.3-rc.1",
+			expErr: gover.ErrInvalidPseudoVersion,
+		},
+		"fail without base version and non-zero minor": {
+			ver:    "v1.2.3-20170915032832-14c0d48ead0c",
+			expErr: gover.ErrInvalidPseudoVersion,
+		},
+		"fail with release base version and zero patch": {
+			ver:    "v1.2.0-0.20170915032832-14c0d48ead0c",
+			expErr: gover.ErrInvalidPseudoVersion,
+		},
+		"fail with invalid timestamp": {
+			ver:    "v0.0.0-20171315032832-14c0d48ead0c",
+			expErr: gover.ErrInvalidPseudoVersion,
+		},
+		"fail with short revision": {
+			ver:    "v0.0.0-20170915032832-14c0d48ead",
+			expErr: gover.ErrInvalidPseudoVersion,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ver := govertest.NewVersion(t, test.ver)
+
+			pv, err := gover.ParsePseudoVersion(ver)
+			require.ErrorIs(t, err, test.expErr)
+			assert.Equal(t, test.expErr == nil, gover.IsPseudoVersion(ver))
+
+			if err != nil {
+				assert.Nil(t, pv)
+
+				return
+			}
+
+			assert.Equal(t, test.ver, pv.String())
+			assert.Equal(t, ver, pv.Version())
+			assert.Equal(t, commitTime, pv.Time())
+			assert.Equal(t, "14c0d48ead0c", pv.Revision())
+
+			if test.expBase == "" {
+				assert.Nil(t, pv.Base())
+
+				return
+			}
+
+			require.NotNil(t, pv.Base())
+			assert.Equal(t, test.expBase, pv.Base().Original())
+		})
+	}
+}
+
+func TestNewPseudoVersion(t *testing.T) {
+	t.Parallel()
+
+	const rev = "14C0D48EAD0C2D7E5E7FCAF2A8F5E1E3F7E2A9B1"
+
+	commitTime := time.Date(2017, 9, 15, 5, 28, 32, 0, time.FixedZone("UTC+2", 2*60*60))
+
+	tests := map[string]struct {
+		major  uint64
+		base   string
+		rev    string
+		exp    string
+		expErr error
+	}{
+		"without base version": {
+			major: 0,
+			rev:   rev,
+			exp:   "v0.0.0-20170915032832-14c0d48ead0c",
+		},
+		"without base version at major version 3": {
+			major: 3,
+			rev:   rev,
+			exp:   "v3.0.0-20170915032832-14c0d48ead0c",
+		},
+		"with pre-release base version": {
+			major: 1,
+			base:  "v1.2.3-rc.1",
+			rev:   rev,
+			exp:   "v1.2.3-rc.1.0.20170915032832-14c0d48ead0c",
+		},
+		"with release base version": {
+			major: 1,
+			base:  "v1.2.3",
+			rev:   rev,
+			exp:   "v1.2.4-0.20170915032832-14c0d48ead0c",
+		},
+		"with incompatible base version": {
+			major: 2,
+			base:  "v2.0.0+incompatible",
+			rev:   rev,
+			exp:   "v2.0.1-0.20170915032832-14c0d48ead0c+incompatible",
+		},
+		"fails with mismatched major version": {
+			major:  2,
+			base:   "v1.2.3",
+			rev:    rev,
+			expErr: gover.ErrInvalidPseudoVersion,
+		},
+		"fails with short revision": {
+			major:  0,
+			rev:    "14c0d48",
+			expErr: gover.ErrInvalidPseudoVersion,
+		},
+		"fails with non-hexadecimal revision": {
+			major:  0,
+			rev:    "main-branch-head",
+			expErr: gover.ErrInvalidPseudoVersion,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var base *semver.Version
+			if test.base != "" {
+				base = govertest.NewVersion(t, test.base)
+			}
+
+			pv, err := gover.NewPseudoVersion(test.major, base, commitTime, test.rev)
+			require.ErrorIs(t, err, test.expErr)
+
+			if err != nil {
+				assert.Nil(t, pv)
+
+				return
+			}
+
+			assert.Equal(t, test.exp, pv.String())
+			assert.Equal(t, commitTime.UTC(), pv.Time())
+			assert.Equal(t, "14c0d48ead0c", pv.Revision())
+
+			if base == nil {
+				assert.Nil(t, pv.Base())
+			} else {
+				assert.Equal(t, base.Original(), pv.Base().Original())
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package gover
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	// QueryLatest selects the highest release version, falling back to
+	// the highest pre-release version when there are no releases.
+	QueryLatest = "latest"
+
+	// QueryUpgrade is like QueryLatest, but keeps the current version
+	// when it's newer than the latest version (e.g. a pre-release.)
+	QueryUpgrade = "upgrade"
+
+	// QueryPatch selects the latest version with the same major and
+	// minor version as the current version.  Without a current version,
+	// QueryPatch is equivalent to QueryLatest.
+	QueryPatch = "patch"
+)
+
+// Resolution is the concrete Go module version number selected by a
+// version query along with an explanation of why it was chosen.
+type Resolution struct {
+	Query   string
+	Version *semver.Version
+	Reason  string
+
+	// Module is the path of the Go module that published the version
+	// or an empty string if the module path isn't known.
+	Module string
+}
+
+// String returns the Resolution's explanation.
+func (r *Resolution) String() string {
+	return r.Reason
+}
+
+// Resolve selects the Go module version number in the Collection that
+// matches the [version query] the same way the go command resolves
+// "go install pkg@query".
+//
+// The following queries are supported:
+//
+//   - A full version (e.g. v1.2.3) selects exactly that version, even
+//     if it's retracted or not present in the Collection.
+//
+//   - A version prefix (e.g. v1 or v1.2) selects the highest version
+//     with that prefix.
+//
+//   - One or more comparisons (e.g. >=v1.4.0 <v2.0.0) select the
+//     highest matching version when there's an upper bound and the
+//     lowest matching version otherwise.  Like the go command, each
+//     comparison requires a full version rather than a prefix.
+//
+//   - latest, upgrade and patch (see QueryLatest, QueryUpgrade and
+//     QueryPatch.)
+//
+// Except for full versions, release versions are preferred over
+// pre-release versions, which are preferred over pseudo-versions, and
+// retracted versions are never selected.  The current version is only
+// used by the upgrade and patch queries and may be nil.
+//
+// [version query]: https://go.dev/ref/mod#version-queries
+func (c *Collection) Resolve(query string, current *semver.Version) (*Resolution, error) {
+	res, err := c.resolve(strings.TrimSpace(query), current)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Module = c.Module(res.Version)
+
+	return res, nil
+}
+
+func (c *Collection) resolve(query string, current *semver.Version) (*Resolution, error) {
+	switch {
+	case query == QueryLatest:
+		return c.resolveLatest(query)
+	case query == QueryUpgrade:
+		return c.resolveUpgrade(query, current)
+	case query == QueryPatch:
+		return c.resolvePatch(query, current)
+	case strings.ContainsAny(query, "<>"):
+		return c.resolveComparison(query)
+	}
+
+	if ver, err := NewVersion(query); err == nil {
+		reason := ver.Original() + " was requested exactly"
+		if !c.contains(ver) {
+			reason += " (it wasn't found in the collected versions)"
+		}
+
+		return &Resolution{Query: query, Version: ver, Reason: reason}, nil
+	}
+
+	return c.resolvePrefix(query)
+}
+
+func (c *Collection) resolveLatest(query string) (*Resolution, error) {
+	ver, kind := c.highest(func(*semver.Version) bool { return true })
+	if ver == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoMatchingVersion, query)
+	}
+
+	return &Resolution{
+		Query:   query,
+		Version: ver,
+		Reason:  fmt.Sprintf("%s is the latest %s", ver.Original(), kind),
+	}, nil
+}
+
+func (c *Collection) resolveUpgrade(query string, current *semver.Version) (*Resolution, error) {
+	res, err := c.resolveLatest(query)
+	if current == nil || (err == nil && Compare(current, res.Version) <= 0) {
+		return res, err
+	}
+
+	return &Resolution{
+		Query:   query,
+		Version: current,
+		Reason:  fmt.Sprintf("%s is the current version and is newer than the latest version", current.Original()),
+	}, nil
+}
+
+func (c *Collection) resolvePatch(query string, current *semver.Version) (*Resolution, error) {
+	if current == nil {
+		return c.resolveLatest(query)
+	}
+
+	ver, kind := c.highest(func(v *semver.Version) bool {
+		return v.Major() == current.Major() && v.Minor() == current.Minor() && Compare(v, current) >= 0
+	})
+	if ver == nil {
+		return &Resolution{
+			Query:   query,
+			Version: current,
+			Reason:  fmt.Sprintf("%s is the current version and there is no newer patch", current.Original()),
+		}, nil
+	}
+
+	return &Resolution{
+		Query:   query,
+		Version: ver,
+		Reason:  fmt.Sprintf("%s is the latest %s of v%d.%d", ver.Original(), kind, current.Major(), current.Minor()),
+	}, nil
+}
+
+func (c *Collection) resolvePrefix(query string) (*Resolution, error) {
+	parts := strings.Split(strings.TrimPrefix(query, GoVersionPrefix), ".")
+	if !strings.HasPrefix(query, GoVersionPrefix) || len(parts) > 2 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidQuery, query)
+	}
+
+	prefix, err := semver.StrictNewVersion(strings.Join(append(parts, "0", "0")[:3], "."))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidQuery, query, err)
+	}
+
+	ver, kind := c.highest(func(v *semver.Version) bool {
+		return v.Major() == prefix.Major() && (len(parts) == 1 || v.Minor() == prefix.Minor())
+	})
+	if ver == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoMatchingVersion, query)
+	}
+
+	return &Resolution{
+		Query:   query,
+		Version: ver,
+		Reason:  fmt.Sprintf("%s is the highest %s with prefix %s", ver.Original(), kind, query),
+	}, nil
+}
+
+func (c *Collection) resolveComparison(query string) (*Resolution, error) {
+	var (
+		bounds []Predicate
+		upper  bool
+	)
+
+	for _, term := range strings.FieldsFunc(query, func(r rune) bool { return r == ' ' || r == ',' }) {
+		op, operand, ok := strings.Cut(term, GoVersionPrefix)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidQuery, term)
+		}
+
+		bound, err := NewVersion(GoVersionPrefix + operand)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidQuery, term, err)
+		}
+
+		var cmp func(int) bool
+
+		switch op {
+		case "<":
+			cmp, upper = func(c int) bool { return c < 0 }, true
+		case "<=":
+			cmp, upper = func(c int) bool { return c <= 0 }, true
+		case ">":
+			cmp = func(c int) bool { return c > 0 }
+		case ">=":
+			cmp = func(c int) bool { return c >= 0 }
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInvalidQuery, term)
+		}
+
+		bounds = append(bounds, func(v *semver.Version) bool { return cmp(Compare(v, bound)) })
+	}
+
+	find, desc := c.lowest, "lowest"
+	if upper {
+		find, desc = c.highest, "highest"
+	}
+
+	ver, kind := find(all(bounds...))
+	if ver == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoMatchingVersion, query)
+	}
+
+	return &Resolution{
+		Query:   query,
+		Version: ver,
+		Reason:  fmt.Sprintf("%s is the %s %s matching %s", ver.Original(), desc, kind, query),
+	}, nil
+}
+
+// highest returns the highest non-retracted version that matches (with
+// the preferences described by Resolve) and the kind of version that was
+// selected.
+func (c *Collection) highest(match Predicate) (*semver.Version, string) {
+	return c.preferred(c.Descending(), match)
+}
+
+// lowest returns the lowest non-retracted version that matches (with
+// the preferences described by Resolve) and the kind of version that was
+// selected.
+func (c *Collection) lowest(match Predicate) (*semver.Version, string) {
+	return c.preferred(c.Ascending(), match)
+}
+
+func (c *Collection) preferred(vers iter.Seq[*semver.Version], match Predicate) (*semver.Version, string) {
+	kinds := []struct {
+		name string
+		is   Predicate
+	}{
+		{"release", IsRelease},
+		{"pre-release", IsTaggedPrerelease},
+		{"pseudo-version", IsPseudoVersion},
+	}
+
+	for _, kind := range kinds {
+		for ver := range vers {
+			if kind.is(ver) && match(ver) && !c.IsRetracted(ver) {
+				return ver, kind.name
+			}
+		}
+	}
+
+	return nil, ""
+}
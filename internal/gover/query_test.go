@@ -0,0 +1,209 @@
+package gover_test
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/gover/govertest"
+)
+
+func TestCollection_Resolve(t *testing.T) {
+	t.Parallel()
+
+	const vers = "v1.0.0 v1.2.0 v1.2.1 v1.2.2 v1.3.0-rc.1 v1.4.0 v1.4.1 v1.5.0 v2.0.0-beta.1 v2.0.0 v2.1.0 v3.0.0-rc.1"
+
+	retracted := gover.Retraction{
+		Low:  govertest.NewVersion(t, "v1.2.2"),
+		High: govertest.NewVersion(t, "v1.2.2"),
+	}
+
+	tests := map[string]struct {
+		vers      string
+		query     string
+		current   string
+		exp       string
+		expReason string
+		expErr    error
+	}{
+		"exact version": {
+			query:     "v1.2.1",
+			exp:       "v1.2.1",
+			expReason: "v1.2.1 was requested exactly",
+		},
+		"exact retracted version": {
+			query: "v1.2.2",
+			exp:   "v1.2.2",
+		},
+		"exact version that wasn't collected": {
+			query:     "v0.0.0-20170915032832-14c0d48ead0c",
+			exp:       "v0.0.0-20170915032832-14c0d48ead0c",
+			expReason: "v0.0.0-20170915032832-14c0d48ead0c was requested exactly (it wasn't found in the collected versions)",
+		},
+		"major version prefix": {
+			query:     "v1",
+			exp:       "v1.5.0",
+			expReason: "v1.5.0 is the highest release with prefix v1",
+		},
+		"minor version prefix skips retracted versions": {
+			query: "v1.2",
+			exp:   "v1.2.1",
+		},
+		"minor version prefix falls back to pre-release": {
+			query:     "v1.3",
+			exp:       "v1.3.0-rc.1",
+			expReason: "v1.3.0-rc.1 is the highest pre-release with prefix v1.3",
+		},
+		"missing version prefix": {
+			query:  "v4",
+			expErr: gover.ErrNoMatchingVersion,
+		},
+		"latest": {
+			query:     "latest",
+			exp:       "v2.1.0",
+			expReason: "v2.1.0 is the latest release",
+		},
+		"latest pre-release without releases": {
+			vers:      "v0.1.0-alpha v0.1.0-beta v0.0.0-20170915032832-14c0d48ead0c",
+			query:     "latest",
+			exp:       "v0.1.0-beta",
+			expReason: "v0.1.0-beta is the latest pre-release",
+		},
+		"latest pseudo-version without tags": {
+			vers:  "v0.0.0-20170915032832-14c0d48ead0c",
+			query: "latest",
+			exp:   "v0.0.0-20170915032832-14c0d48ead0c",
+		},
+		"latest without versions": {
+			vers:   " ",
+			query:  "latest",
+			expErr: gover.ErrNoMatchingVersion,
+		},
+		"upgrade without current version": {
+			query: "upgrade",
+			exp:   "v2.1.0",
+		},
+		"upgrade from older version": {
+			query:   "upgrade",
+			current: "v1.4.0",
+			exp:     "v2.1.0",
+		},
+		"upgrade keeps newer pre-release": {
+			query:     "upgrade",
+			current:   "v3.0.0-rc.1",
+			exp:       "v3.0.0-rc.1",
+			expReason: "v3.0.0-rc.1 is the current version and is newer than the latest version",
+		},
+		"patch": {
+			query:     "patch",
+			current:   "v1.4.0",
+			exp:       "v1.4.1",
+			expReason: "v1.4.1 is the latest release of v1.4",
+		},
+		"patch skips retracted versions": {
+			query:   "patch",
+			current: "v1.2.0",
+			exp:     "v1.2.1",
+		},
+		"patch keeps current version": {
+			query:   "patch",
+			current: "v1.5.0",
+			exp:     "v1.5.0",
+		},
+		"patch without current version": {
+			query: "patch",
+			exp:   "v2.1.0",
+		},
+		"upper and lower bounds select highest": {
+			query:     ">=v1.4.0 <v2.0.0",
+			exp:       "v1.5.0",
+			expReason: "v1.5.0 is the highest release matching >=v1.4.0 <v2.0.0",
+		},
+		"upper bound selects highest": {
+			query: "<=v1.4.1",
+			exp:   "v1.4.1",
+		},
+		"lower bound selects lowest": {
+			query:     ">v1.4.0",
+			exp:       "v1.4.1",
+			expReason: "v1.4.1 is the lowest release matching >v1.4.0",
+		},
+		"comma separated bounds": {
+			query: ">v1.0.0, <v1.3.0",
+			exp:   "v1.2.1",
+		},
+		"bounds prefer releases": {
+			query: ">=v2.0.0-alpha <v2.1.0",
+			exp:   "v2.0.0",
+		},
+		"bounds without a match": {
+			query:  ">v3.0.0",
+			expErr: gover.ErrNoMatchingVersion,
+		},
+		"invalid operator": {
+			query:  "=>v1.0.0",
+			expErr: gover.ErrInvalidQuery,
+		},
+		"invalid operand": {
+			query:  ">=1.0.0",
+			expErr: gover.ErrInvalidQuery,
+		},
+		"partial upper bound": {
+			query:  "<=v1.2",
+			expErr: gover.ErrInvalidQuery,
+		},
+		"partial major bound": {
+			query:  "<v1",
+			expErr: gover.ErrInvalidQuery,
+		},
+		"partial lower bound": {
+			query:  ">=v1.4 <v2.0.0",
+			expErr: gover.ErrInvalidQuery,
+		},
+		"invalid query": {
+			query:  "main",
+			expErr: gover.ErrInvalidQuery,
+		},
+		"invalid prefix": {
+			query:  "v1.x",
+			expErr: gover.ErrInvalidQuery,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if test.vers == "" {
+				test.vers = vers
+			}
+
+			col := govertest.NewCollection(t, test.vers).WithRetractions(retracted)
+
+			var current *semver.Version
+			if test.current != "" {
+				current = govertest.NewVersion(t, test.current)
+			}
+
+			res, err := col.Resolve(test.query, current)
+			require.ErrorIs(t, err, test.expErr)
+
+			if err != nil {
+				assert.Nil(t, res)
+
+				return
+			}
+
+			assert.Equal(t, test.query, res.Query)
+			assert.Equal(t, test.exp, res.Version.Original())
+
+			if test.expReason != "" {
+				assert.Equal(t, test.expReason, res.Reason)
+				assert.Equal(t, test.expReason, res.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package gover
+
+import (
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+	"golang.org/x/mod/modfile"
+)
+
+// Retraction is a range of Go module version numbers that the module's
+// author has retracted using a [retract directive] in the module's
+// go.mod file.
+//
+// [retract directive]: https://go.dev/ref/mod#go-mod-file-retract
+type Retraction struct {
+	// Low is the lowest retracted version.
+	Low *semver.Version
+
+	// High is the highest retracted version.  High is equal to Low
+	// when a single version is retracted.
+	High *semver.Version
+
+	// Rationale is the comment that the module's author provided to
+	// explain the retraction.  Rationale may be empty.
+	Rationale string
+}
+
+// Contains returns a boolean value indicating whether the Go module
+// version number falls within the retracted range.
+func (r Retraction) Contains(ver *semver.Version) bool {
+	return Compare(r.Low, ver) <= 0 && Compare(ver, r.High) <= 0
+}
+
+// ParseRetractions returns the retracted ranges declared by the retract
+// directives in the contents of a go.mod file.  The file name is only
+// used in error messages.
+func ParseRetractions(file string, data []byte) ([]Retraction, error) {
+	f, err := modfile.ParseLax(file, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := make([]Retraction, 0, len(f.Retract))
+
+	for _, r := range f.Retract {
+		low, err := NewVersion(r.Low)
+		if err != nil {
+			return nil, err
+		}
+
+		high, err := NewVersion(r.High)
+		if err != nil {
+			return nil, err
+		}
+
+		rs = append(rs, Retraction{
+			Low:       low,
+			High:      high,
+			Rationale: r.Rationale,
+		})
+	}
+
+	return rs, nil
+}
+
+// IsRetracted returns a boolean value indicating whether the Go module
+// version number has been retracted by the module's author.
+func (c *Collection) IsRetracted(ver *semver.Version) bool {
+	_, ok := c.Retraction(ver)
+
+	return ok
+}
+
+// RetractionNotes returns a line for each retracted version in the
+// Collection that names the version and, when the module's author
+// provided one, the rationale for the retraction (e.g. "v1.0.1 is
+// retracted: Published with a broken build.")
+func (c *Collection) RetractionNotes() []string {
+	var notes []string
+
+	for _, ver := range c.col {
+		r, ok := c.Retraction(ver)
+		if !ok {
+			continue
+		}
+
+		note := ver.Original() + " is retracted"
+		if r.Rationale != "" {
+			note += ": " + r.Rationale
+		}
+
+		notes = append(notes, note)
+	}
+
+	return notes
+}
+
+// Retraction returns the Retraction that covers the Go module version
+// number and a boolean value indicating whether any Retraction was
+// found.
+func (c *Collection) Retraction(ver *semver.Version) (Retraction, bool) {
+	for _, r := range c.retractions {
+		if r.Contains(ver) {
+			return r, true
+		}
+	}
+
+	return Retraction{}, false
+}
+
+// Retractions returns the retracted ranges that apply to the
+// Collection.
+func (c *Collection) Retractions() []Retraction {
+	return slices.Clone(c.retractions)
+}
+
+// WithRetractions creates a clone of the Collection that includes the
+// provided retracted ranges.
+func (c *Collection) WithRetractions(rs ...Retraction) *Collection {
+	clone := c.clone()
+	clone.retractions = append(clone.retractions, rs...)
+
+	return clone
+}
+
+// WithoutRetracted creates a clone of the Collection that excludes the
+// retracted Go module version numbers.
+func (c *Collection) WithoutRetracted() *Collection {
+	return c.Filter(Not(c.IsRetracted))
+}
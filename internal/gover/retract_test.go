@@ -0,0 +1,87 @@
+package gover_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/gover/govertest"
+)
+
+func TestCollection_Retractions(t *testing.T) {
+	t.Parallel()
+
+	col := govertest.NewCollection(t, "v0.9.0 v1.0.0 v1.0.1 v1.1.0 v1.2.0 v1.2.1 v1.3.0-rc.1").WithRetractions(
+		gover.Retraction{
+			Low:       govertest.NewVersion(t, "v1.0.1"),
+			High:      govertest.NewVersion(t, "v1.0.1"),
+			Rationale: "Published with a broken build.",
+		},
+		gover.Retraction{
+			Low:  govertest.NewVersion(t, "v1.2.0"),
+			High: govertest.NewVersion(t, "v1.2.9"),
+		},
+	)
+
+	t.Run("IsRetracted", func(t *testing.T) {
+		t.Parallel()
+
+		assert.False(t, col.IsRetracted(govertest.NewVersion(t, "v1.0.0")))
+		assert.True(t, col.IsRetracted(govertest.NewVersion(t, "v1.0.1")))
+		assert.True(t, col.IsRetracted(govertest.NewVersion(t, "v1.2.0")))
+		assert.True(t, col.IsRetracted(govertest.NewVersion(t, "v1.2.1")))
+		assert.False(t, col.IsRetracted(govertest.NewVersion(t, "v1.3.0-rc.1")))
+	})
+
+	t.Run("Retraction", func(t *testing.T) {
+		t.Parallel()
+
+		r, ok := col.Retraction(govertest.NewVersion(t, "v1.0.1"))
+		require.True(t, ok)
+		assert.Equal(t, "Published with a broken build.", r.Rationale)
+
+		_, ok = col.Retraction(govertest.NewVersion(t, "v1.1.0"))
+		assert.False(t, ok)
+	})
+
+	t.Run("LatestStable skips retracted versions", func(t *testing.T) {
+		t.Parallel()
+
+		act, err := col.LatestStable()
+		require.NoError(t, err)
+		assert.Equal(t, "v1.1.0", act.Original())
+	})
+
+	t.Run("RetractionNotes", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, []string{
+			"v1.0.1 is retracted: Published with a broken build.",
+			"v1.2.0 is retracted",
+			"v1.2.1 is retracted",
+		}, col.RetractionNotes())
+	})
+
+	t.Run("WithoutRetracted leaves the original unchanged", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, 4, col.WithoutRetracted().Len())
+		assert.Equal(t, 7, col.Len())
+		assert.Len(t, col.Retractions(), 2)
+	})
+
+	t.Run("No LatestStable when all releases are retracted", func(t *testing.T) {
+		t.Parallel()
+
+		col := govertest.NewCollection(t, "v1.0.0 v1.1.0-rc.1").WithRetractions(gover.Retraction{
+			Low:  govertest.NewVersion(t, "v1.0.0"),
+			High: govertest.NewVersion(t, "v1.0.0"),
+		})
+
+		ver, err := col.LatestStable()
+		require.ErrorIs(t, err, gover.ErrNoStableVersion)
+		assert.Nil(t, ver)
+	})
+}
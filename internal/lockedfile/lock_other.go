@@ -0,0 +1,21 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package lockedfile
+
+import "os"
+
+// asdf only supports the platforms that provide flock(2), so files are
+// only replaced atomically (without locking or flushing the directory)
+// everywhere else.
+
+func flock(*os.File, bool) error {
+	return nil
+}
+
+func funlock(*os.File) error {
+	return nil
+}
+
+func syncDir(string) error {
+	return nil
+}
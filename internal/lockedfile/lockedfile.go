@@ -0,0 +1,196 @@
+// Package lockedfile reads and writes files that are shared between
+// concurrent asdf processes.
+//
+// Each file is protected by an advisory lock on a sibling file with the
+// LockSuffix and is replaced atomically (by renaming a temporary file)
+// so that readers never observe a partially written file.
+package lockedfile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// LockSuffix is appended to a file's path to create the path of the
+// file that holds its advisory lock.
+const LockSuffix = ".lock"
+
+// Lock acquires an exclusive lock on the file at the provided path,
+// blocking until the lock is available, and returns the function that
+// releases the lock.
+func Lock(path string) (func() error, error) {
+	return lock(path, true)
+}
+
+// RLock acquires a shared lock on the file at the provided path,
+// blocking while another process holds an exclusive lock, and returns
+// the function that releases the lock.
+func RLock(path string) (func() error, error) {
+	return lock(path, false)
+}
+
+// Read returns the contents of the file while holding a shared lock.
+//
+// The file is read without a lock if the lock file can't be created
+// because the directory isn't writable, since no other process can
+// replace the file in that directory either.
+func Read(path string) ([]byte, error) {
+	// Avoid creating a lock file (and its directory) for a file that
+	// doesn't exist.
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	unlock, err := RLock(path)
+	if errors.Is(err, fs.ErrPermission) {
+		return os.ReadFile(path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+
+	return data, errors.Join(err, unlock())
+}
+
+// Write atomically replaces the contents of the file while holding an
+// exclusive lock.
+func Write(path string, data []byte, perm fs.FileMode) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(WriteUnlocked(path, data, perm), unlock())
+}
+
+// Update atomically replaces the contents of the file with the result of
+// the provided function while holding an exclusive lock, so that no
+// other process can modify the file between the read and the write.
+//
+// The function receives a nil slice if the file doesn't exist.
+func Update(path string, perm fs.FileMode, fn func([]byte) ([]byte, error)) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(update(path, perm, fn), unlock())
+}
+
+// Remove deletes the file while holding its exclusive lock.  The
+// function receives the file's fs.FileInfo and the file is only removed
+// if it returns true, so that a file replaced by another process while
+// waiting for the lock can be kept.
+//
+// The lock file is never deleted, since another process may already be
+// waiting on it and would then hold a lock that excludes no one (the
+// go command's lockedfile package leaves its lock files for the same
+// reason.)  A file that doesn't exist isn't an error.
+func Remove(path string, fn func(fs.FileInfo) bool) error {
+	// Avoid creating a lock file for a file that doesn't exist.
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(remove(path, fn), unlock())
+}
+
+func remove(path string, fn func(fs.FileInfo) bool) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !fn(info) {
+		return nil
+	}
+
+	return os.Remove(path)
+}
+
+func update(path string, perm fs.FileMode, fn func([]byte) ([]byte, error)) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	data, err = fn(data)
+	if err != nil {
+		return err
+	}
+
+	return WriteUnlocked(path, data, perm)
+}
+
+func lock(path string, exclusive bool) (func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path+LockSuffix, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := flock(f, exclusive); err != nil {
+		return nil, errors.Join(err, f.Close())
+	}
+
+	return func() error {
+		return errors.Join(funlock(f), f.Close())
+	}, nil
+}
+
+// WriteUnlocked atomically replaces the contents of the file without
+// acquiring its lock.  It's used to write the files that are protected
+// by another file's lock (e.g. a backup written while the caller holds
+// the original's exclusive lock.)
+//
+// The data is written to a temporary file in the same directory (and
+// therefore on the same file system) as the destination, which is
+// renamed once its contents have been flushed to disk.  The directory
+// is then flushed so that the rename survives a crash.
+func WriteUnlocked(path string, data []byte, perm fs.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		return errors.Join(err, f.Close())
+	}
+
+	if err := f.Sync(); err != nil {
+		return errors.Join(err, f.Close())
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(f.Name(), perm); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+
+	return syncDir(filepath.Dir(path))
+}
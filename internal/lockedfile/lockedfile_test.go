@@ -0,0 +1,177 @@
+package lockedfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/lockedfile"
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nested", "file.json")
+
+	require.NoError(t, lockedfile.Write(path, []byte("first"), 0o644))
+	require.NoError(t, lockedfile.Write(path, []byte("second"), 0o600))
+
+	data, err := lockedfile.Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "only the file and its lock should remain")
+}
+
+func TestWriteUnlocked(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "file.json")
+
+	require.NoError(t, lockedfile.WriteUnlocked(path, []byte("first"), 0o644))
+	require.NoError(t, lockedfile.WriteUnlocked(path, []byte("second"), 0o644))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no lock or temporary file should remain")
+}
+
+func TestRead_NotExist(t *testing.T) {
+	t.Parallel()
+
+	_, err := lockedfile.Read(filepath.Join(t.TempDir(), "missing.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRead_ReadOnlyDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(path, []byte("read-only"), 0o644))
+	require.NoError(t, os.Chmod(dir, 0o555))
+	t.Cleanup(func() { _ = os.Chmod(dir, 0o755) })
+
+	if _, err := os.Create(filepath.Join(dir, "probe")); err == nil {
+		t.Skip("directory permissions aren't enforced")
+	}
+
+	data, err := lockedfile.Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, "read-only", string(data))
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	const writers = 20
+
+	path := filepath.Join(t.TempDir(), "counter")
+
+	var wg sync.WaitGroup
+
+	for range writers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			assert.NoError(t, lockedfile.Update(path, 0o644, func(data []byte) ([]byte, error) {
+				var n int
+
+				if data != nil {
+					var err error
+
+					n, err = strconv.Atoi(string(data))
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				return []byte(strconv.Itoa(n + 1)), nil
+			}))
+		}()
+	}
+
+	wg.Wait()
+
+	data, err := lockedfile.Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(writers), string(data))
+}
+
+func TestUpdate_Error(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, lockedfile.Write(path, []byte("unchanged"), 0o644))
+
+	err := lockedfile.Update(path, 0o644, func([]byte) ([]byte, error) {
+		return nil, os.ErrInvalid
+	})
+	require.ErrorIs(t, err, os.ErrInvalid)
+
+	data, err := lockedfile.Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, "unchanged", string(data))
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		keep      bool
+		expExists bool
+	}{
+		"removed": {keep: false, expExists: false},
+		"kept":    {keep: true, expExists: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "file")
+			require.NoError(t, lockedfile.Write(path, []byte("data"), 0o644))
+
+			require.NoError(t, lockedfile.Remove(path, func(info os.FileInfo) bool {
+				assert.Equal(t, int64(len("data")), info.Size())
+
+				return !test.keep
+			}))
+
+			_, err := os.Stat(path)
+			assert.Equal(t, test.expExists, err == nil)
+
+			_, err = os.Stat(path + lockedfile.LockSuffix)
+			assert.NoError(t, err, "the lock file should never be removed")
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "missing")
+
+		require.NoError(t, lockedfile.Remove(path, func(os.FileInfo) bool { return true }))
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
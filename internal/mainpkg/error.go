@@ -0,0 +1,20 @@
+package mainpkg
+
+import "errors"
+
+// ErrModuleMismatch is returned when the go.mod file in a module zip
+// declares a module path other than the one requested from the module
+// proxy.
+var ErrModuleMismatch = errors.New("go.mod declares a different module path")
+
+// ErrMultiplePackages is returned when a directory in the module zip
+// contains Go source files that declare different packages.
+var ErrMultiplePackages = errors.New("directory declares more than one package")
+
+// ErrNotCommand is returned when the Go package exists but isn't an
+// installable command (i.e. it isn't "package main".)
+var ErrNotCommand = errors.New("package is not a command (package main)")
+
+// ErrPackageNotFound is returned when the module doesn't contain a
+// directory with Go source files for the package.
+var ErrPackageNotFound = errors.New("package not found in module")
@@ -0,0 +1,265 @@
+// Package mainpkg resolves the module that provides a Go package and
+// validates that the package is a command that "go install" can build,
+// so that typos and library paths are rejected when a plugin is created
+// instead of when a tool is installed.
+package mainpkg
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/goproxy"
+)
+
+const mainPackageName = "main"
+
+// Package describes a Go command and the module that provides it.
+type Package struct {
+	// Path is the package's import path.
+	Path string
+
+	// Module is the path of the module that provides the package.
+	Module string
+
+	// Subpath is the package's directory relative to the module root
+	// or an empty string if the package is the module's root package.
+	Subpath string
+
+	// Version is the module version that was inspected.
+	Version string
+}
+
+// Resolve finds the module that provides the Go package (see
+// goproxy.Client.ModuleRoot) and inspects the module zip of its latest
+// version to confirm that the package is a command.
+//
+// ErrPackageNotFound is returned if the module has no Go source files in
+// the package's directory and ErrNotCommand is returned if the package
+// isn't "package main".  Files excluded by build constraints are ignored
+// (see packages.)
+func Resolve(ctx context.Context, cfg *config.Config, pkg string) (*Package, error) {
+	c, err := goproxy.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	mod, err := c.ModuleRoot(ctx, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	ver, pkgs, err := packages(ctx, cfg, c, mod, "")
+	if err != nil {
+		return nil, err
+	}
+
+	subpath := strings.TrimPrefix(strings.TrimPrefix(pkg, mod), "/")
+
+	name, ok := pkgs[subpath]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s@%s has no Go files in %s", ErrPackageNotFound, mod, ver, pkg)
+	}
+
+	if name != mainPackageName {
+		return nil, fmt.Errorf("%w: %s", ErrNotCommand, pkg)
+	}
+
+	return &Package{
+		Path:    pkg,
+		Module:  mod,
+		Subpath: subpath,
+		Version: ver,
+	}, nil
+}
+
+// Commands lists every command (package main) in the module zip of the
+// provided version of the module, sorted by import path.  An empty
+// version selects the module's latest version.
+//
+// Directories that the go command ignores (testdata and those starting
+// with "." or "_") aren't included.
+func Commands(ctx context.Context, cfg *config.Config, mod string, ver string) ([]*Package, error) {
+	c, err := goproxy.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ver, pkgs, err := packages(ctx, cfg, c, mod, ver)
+	if err != nil {
+		return nil, err
+	}
+
+	var cmds []*Package
+
+	for subpath, name := range pkgs {
+		if name != mainPackageName || isIgnoredDir(subpath) {
+			continue
+		}
+
+		cmds = append(cmds, &Package{
+			Path:    path.Join(mod, subpath),
+			Module:  mod,
+			Subpath: subpath,
+			Version: ver,
+		})
+	}
+
+	slices.SortFunc(cmds, func(a, b *Package) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return cmds, nil
+}
+
+// packages downloads the module zip and returns the resolved version
+// along with the package name declared in each directory (relative to
+// the module root) that contains Go source files.  As with the go
+// command, files that are excluded by their build constraints (e.g.
+// "//go:build ignore") or their _GOOS/_GOARCH suffix aren't considered.
+// The module zip's go.mod file (if any) must declare the requested
+// module path and ErrMultiplePackages is returned if a directory
+// declares more than one package.
+func packages(ctx context.Context, cfg *config.Config, c *goproxy.Client, mod string, ver string) (string, map[string]string, error) {
+	if ver == "" {
+		info, err := c.Latest(ctx, mod)
+		if err != nil {
+			return "", nil, err
+		}
+
+		ver = info.Version
+	}
+
+	cfg.Log().Debug(
+		"Inspecting module zip",
+		slog.String("module", mod),
+		slog.String("version", ver),
+	)
+
+	data, err := c.Zip(ctx, mod, ver)
+	if err != nil {
+		return "", nil, err
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", nil, fmt.Errorf("%s@%s: %w", mod, ver, err)
+	}
+
+	var (
+		root  = mod + "@" + ver + "/"
+		pkgs  = map[string]string{}
+		fset  = token.NewFileSet()
+		bctx  = zipContext(zr)
+		files = map[string]string{}
+	)
+
+	for _, f := range zr.File {
+		name, ok := strings.CutPrefix(f.Name, root)
+
+		switch {
+		case !ok:
+			continue
+		case name == "go.mod":
+			src, err := readFile(f)
+			if err != nil {
+				return "", nil, err
+			}
+
+			if declared := modfile.ModulePath(src); declared != mod {
+				return "", nil, fmt.Errorf("%w: %s@%s declares %s", ErrModuleMismatch, mod, ver, declared)
+			}
+		case isSourceFile(path.Base(name)):
+			match, err := bctx.MatchFile(path.Dir(f.Name), path.Base(f.Name))
+			if err != nil {
+				return "", nil, err
+			}
+
+			if !match {
+				cfg.Log().Debug("Skipping excluded source file", slog.String("file", name))
+
+				continue
+			}
+
+			src, err := readFile(f)
+			if err != nil {
+				return "", nil, err
+			}
+
+			file, err := parser.ParseFile(fset, f.Name, src, parser.PackageClauseOnly)
+			if err != nil {
+				return "", nil, err
+			}
+
+			dir := path.Dir(name)
+			if dir == "." {
+				dir = ""
+			}
+
+			if prev, ok := pkgs[dir]; ok && prev != file.Name.Name {
+				return "", nil, fmt.Errorf("%w: %s@%s declares package %s in %s and package %s in %s", ErrMultiplePackages, mod, ver, prev, files[dir], file.Name.Name, name)
+			}
+
+			pkgs[dir] = file.Name.Name
+			files[dir] = name
+		}
+	}
+
+	return ver, pkgs, nil
+}
+
+// isIgnoredDir reports whether the go command ignores any element of the
+// directory when matching package patterns like ./...
+func isIgnoredDir(dir string) bool {
+	for _, elem := range strings.Split(dir, "/") {
+		if elem == "testdata" || strings.HasPrefix(elem, ".") || strings.HasPrefix(elem, "_") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSourceFile reports whether the go command would consider the file
+// when building the package.
+func isSourceFile(name string) bool {
+	return strings.HasSuffix(name, ".go") &&
+		!strings.HasSuffix(name, "_test.go") &&
+		!strings.HasPrefix(name, "_") &&
+		!strings.HasPrefix(name, ".")
+}
+
+// zipContext returns a copy of build.Default that reads the files it
+// matches from the module zip instead of the file system.
+func zipContext(zr *zip.Reader) *build.Context {
+	bctx := build.Default
+	bctx.JoinPath = path.Join
+	bctx.OpenFile = func(name string) (io.ReadCloser, error) {
+		return zr.Open(name)
+	}
+
+	return &bctx
+}
+
+func readFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,245 @@
+package mainpkg_test
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/goproxy"
+	"github.com/selesy/asdf-go-install/internal/mainpkg"
+	"github.com/selesy/asdf-go-install/internal/transport"
+)
+
+// modules maps each module path served by the test proxy to the files
+// in its v1.1.0 zip.
+var modules = map[string]map[string]string{
+	"example.com/tool": {
+		"go.mod":              "module example.com/tool\n",
+		"tool.go":             "package tool\n",
+		"gen.go":              "//go:build ignore\n\npackage main\n",
+		"cmd/tool/main.go":    "// Command tool does things.\npackage main\n",
+		"cmd/tool/tool.go":    "package main\n",
+		"cmd/tool/x_test.go":  "package main_test\n",
+		"cmd/gen/main.go":     "package main\n",
+		"internal/lib/lib.go": "package lib\n",
+		"docs/README.md":      "# Tool\n",
+		"testdata/x/main.go":  "package main\n",
+		"_examples/main.go":   "package main\n",
+		".hidden/cmd/main.go": "package main\n",
+	},
+	"example.com/legacy": {
+		"main.go": "package main\n",
+	},
+	"example.com/mixed": {
+		"go.mod":  "module example.com/mixed\n",
+		"main.go": "package main\n",
+		"lib.go":  "package lib\n",
+	},
+	"example.com/renamed": {
+		"go.mod":  "module example.com/other\n",
+		"main.go": "package main\n",
+	},
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(serve))
+	t.Cleanup(srv.Close)
+
+	tests := map[string]struct {
+		pkg        string
+		expModule  string
+		expSubpath string
+		expErr     error
+	}{
+		"pass with command in sub-directory": {
+			pkg:        "example.com/tool/cmd/tool",
+			expModule:  "example.com/tool",
+			expSubpath: "cmd/tool",
+		},
+		"pass with module root command without go.mod": {
+			pkg:       "example.com/legacy",
+			expModule: "example.com/legacy",
+		},
+		"fail with library package": {
+			pkg:    "example.com/tool/internal/lib",
+			expErr: mainpkg.ErrNotCommand,
+		},
+		"fail with root package and ignored command file": {
+			pkg:    "example.com/tool",
+			expErr: mainpkg.ErrNotCommand,
+		},
+		"fail with multiple packages in directory": {
+			pkg:    "example.com/mixed",
+			expErr: mainpkg.ErrMultiplePackages,
+		},
+		"fail with typo": {
+			pkg:    "example.com/tool/cmd/tooll",
+			expErr: mainpkg.ErrPackageNotFound,
+		},
+		"fail with directory without Go files": {
+			pkg:    "example.com/tool/docs",
+			expErr: mainpkg.ErrPackageNotFound,
+		},
+		"fail with mismatched go.mod": {
+			pkg:    "example.com/renamed",
+			expErr: mainpkg.ErrModuleMismatch,
+		},
+		"fail with unknown module": {
+			pkg:    "example.com/missing/cmd/missing",
+			expErr: goproxy.ErrNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, _ := configtest.NewConfig(t, []string{"GOPROXY=" + srv.URL}, []string{})
+
+			p, err := mainpkg.Resolve(context.Background(), cfg, test.pkg)
+			require.ErrorIs(t, err, test.expErr)
+
+			if err != nil {
+				assert.Nil(t, p)
+
+				return
+			}
+
+			assert.Equal(t, test.pkg, p.Path)
+			assert.Equal(t, test.expModule, p.Module)
+			assert.Equal(t, test.expSubpath, p.Subpath)
+			assert.Equal(t, "v1.1.0", p.Version)
+		})
+	}
+}
+
+func TestResolve_Offline(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"AGI_OFFLINE=true"}, []string{})
+
+	p, err := mainpkg.Resolve(context.Background(), cfg, "example.com/tool/cmd/tool")
+	require.ErrorIs(t, err, transport.ErrOffline)
+	assert.Nil(t, p)
+}
+
+func TestCommands(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(serve))
+	t.Cleanup(srv.Close)
+
+	tests := map[string]struct {
+		mod    string
+		ver    string
+		exp    []string
+		expErr error
+	}{
+		"pass with latest version": {
+			mod: "example.com/tool",
+			exp: []string{"example.com/tool/cmd/gen", "example.com/tool/cmd/tool"},
+		},
+		"pass with explicit version": {
+			mod: "example.com/tool",
+			ver: "v1.1.0",
+			exp: []string{"example.com/tool/cmd/gen", "example.com/tool/cmd/tool"},
+		},
+		"pass with module root command": {
+			mod: "example.com/legacy",
+			exp: []string{"example.com/legacy"},
+		},
+		"fail with mismatched go.mod": {
+			mod:    "example.com/renamed",
+			expErr: mainpkg.ErrModuleMismatch,
+		},
+		"fail with multiple packages in directory": {
+			mod:    "example.com/mixed",
+			expErr: mainpkg.ErrMultiplePackages,
+		},
+		"fail with unknown version": {
+			mod:    "example.com/tool",
+			ver:    "v9.9.9",
+			expErr: goproxy.ErrNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, _ := configtest.NewConfig(t, []string{"GOPROXY=" + srv.URL}, []string{})
+
+			cmds, err := mainpkg.Commands(context.Background(), cfg, test.mod, test.ver)
+			require.ErrorIs(t, err, test.expErr)
+
+			var paths []string
+
+			for _, cmd := range cmds {
+				assert.Equal(t, test.mod, cmd.Module)
+				assert.Equal(t, "v1.1.0", cmd.Version)
+
+				paths = append(paths, cmd.Path)
+			}
+
+			assert.Equal(t, test.exp, paths)
+		})
+	}
+}
+
+func TestCommands_Offline(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"AGI_OFFLINE=true"}, []string{})
+
+	cmds, err := mainpkg.Commands(context.Background(), cfg, "example.com/tool", "")
+	require.ErrorIs(t, err, transport.ErrOffline)
+	assert.Nil(t, cmds)
+}
+
+// serve implements the parts of the GOPROXY protocol used by Resolve
+// and Commands for the modules above.
+func serve(w http.ResponseWriter, r *http.Request) {
+	mod, suffix, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/@")
+
+	files, ok := modules[mod]
+	if !ok {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	switch suffix {
+	case "v/list":
+		_, _ = io.WriteString(w, "v1.0.0\nv1.1.0\n")
+	case "latest":
+		_, _ = io.WriteString(w, `{"Version":"v1.1.0","Time":"2024-01-02T03:04:05Z"}`)
+	case "v/v1.1.0.zip":
+		zw := zip.NewWriter(w)
+
+		for name, content := range files {
+			f, err := zw.Create(path.Join(mod+"@v1.1.0", name))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+
+				return
+			}
+
+			_, _ = io.WriteString(f, content)
+		}
+
+		_ = zw.Close()
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,16 @@
+package manifest
+
+import "errors"
+
+// ErrInvalidManifest is returned when a manifest file doesn't match
+// the manifest's JSON Schema or its packages can't be installed
+// together.
+var ErrInvalidManifest = errors.New("invalid manifest")
+
+// ErrRepositoryNotFound is returned when none of the provided
+// RepositoryResolvers find the Go package's Git repository.
+var ErrRepositoryNotFound = errors.New("repository not found")
+
+// ErrUnsupportedVersion is returned when a manifest file's version
+// isn't one that can be read.
+var ErrUnsupportedVersion = errors.New("unsupported manifest version")
@@ -0,0 +1,106 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/selesy/asdf-go-install/internal/lockedfile"
+)
+
+// LastGoodFilename is the name of the copy of the most recently written
+// valid manifest that's kept in the plugin's directory.  Read restores
+// it if the manifest file is found to be corrupted (e.g. truncated by a
+// process that crashed while writing it.)
+const LastGoodFilename = ManifestFilename + ".last-good"
+
+// CorruptFilename is the name of the copy of a corrupted manifest file
+// that Read saves in the plugin's directory before restoring the
+// LastGoodFilename, so that the corrupted data can still be inspected.
+const CorruptFilename = ManifestFilename + ".corrupt" + backupSuffix
+
+// The manifest file and the files kept next to it (the last good copy
+// and the backups made by upgrade) are only written while holding the
+// manifest file's exclusive lock (see lockedfile.)  Readers hold its
+// shared lock so they never observe a partially written manifest.
+
+// write atomically replaces the manifest file at path and saves the
+// data as the LastGoodFilename.  The data must be a valid manifest (see
+// Manifest.Write.)
+func write(path string, data []byte) error {
+	return lockedfile.Update(path, 0o644, func([]byte) ([]byte, error) {
+		if err := lockedfile.WriteUnlocked(lastGoodPath(path), data, 0o644); err != nil {
+			return nil, err
+		}
+
+		return data, nil
+	})
+}
+
+// restore saves the corrupted manifest file at path as the
+// CorruptFilename, replaces it with the LastGoodFilename and returns the
+// restored data.  The cause is returned if there's no last good copy.
+// A manifest file that's no longer corrupted (because another process
+// has already replaced it) is left unchanged.
+func restore(path string, cause error) ([]byte, error) {
+	var restored []byte
+
+	err := lockedfile.Update(path, 0o644, func(data []byte) ([]byte, error) {
+		if _, _, err := decode(data); !corrupted(err) {
+			restored = data
+
+			return data, nil
+		}
+
+		good, err := os.ReadFile(lastGoodPath(path))
+		if err != nil {
+			return nil, err
+		}
+
+		if _, _, err := decode(good); err != nil {
+			return nil, fmt.Errorf("%s: %w", LastGoodFilename, err)
+		}
+
+		if err := lockedfile.WriteUnlocked(corruptPath(path), data, 0o644); err != nil {
+			return nil, err
+		}
+
+		restored = good
+
+		return good, nil
+	})
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, cause
+	case err != nil:
+		return nil, fmt.Errorf("%w (restoring %s failed: %w)", cause, LastGoodFilename, err)
+	default:
+		return restored, nil
+	}
+}
+
+// corrupted indicates that the error returned when decoding a manifest
+// file means the file isn't valid JSON (e.g. because it was truncated by
+// a process that crashed while writing it,) which is fixed by restoring
+// the last good copy.  Manifests that are valid JSON but fail validation
+// (e.g. because of a mistake made while editing them by hand) and
+// manifests with an unsupported version are left alone so the problem
+// is reported instead.
+func corrupted(err error) bool {
+	var syntaxErr *json.SyntaxError
+
+	return errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func corruptPath(path string) string {
+	return filepath.Join(filepath.Dir(path), CorruptFilename)
+}
+
+func lastGoodPath(path string) string {
+	return filepath.Join(filepath.Dir(path), LastGoodFilename)
+}
@@ -0,0 +1,241 @@
+package manifest_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gotest.tools/v3/golden"
+
+	"github.com/selesy/asdf-go-install/internal/goinstall"
+	"github.com/selesy/asdf-go-install/internal/lockedfile"
+	"github.com/selesy/asdf-go-install/internal/manifest"
+	"github.com/selesy/asdf-go-install/internal/pkgsite"
+)
+
+func TestManifest_Write_LastGood(t *testing.T) {
+	t.Parallel()
+
+	cfg, path := pluginConfig(t, "manifest-v3.json")
+	dir := filepath.Dir(path)
+
+	man := manifest.New(name, pkg, packageURL(t)).WithGitReference(tagReference(t))
+	require.NoError(t, man.Write(cfg, name))
+
+	act, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	good, err := os.ReadFile(filepath.Join(dir, manifest.LastGoodFilename))
+	require.NoError(t, err)
+	assert.Equal(t, act, good)
+	assert.FileExists(t, path+lockedfile.LockSuffix)
+
+	// An invalid manifest isn't written at all.
+	invalid := man.WithBuildOptions(&goinstall.BuildOptions{BinaryName: "../enum"})
+	require.ErrorIs(t, invalid.Write(cfg, name), manifest.ErrInvalidManifest)
+
+	act2, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, act, act2)
+
+	good2, err := os.ReadFile(filepath.Join(dir, manifest.LastGoodFilename))
+	require.NoError(t, err)
+	assert.Equal(t, good, good2)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	assert.ElementsMatch(t, []string{
+		manifest.ManifestFilename,
+		manifest.ManifestFilename + lockedfile.LockSuffix,
+		manifest.LastGoodFilename,
+	}, names, "temporary files should be removed")
+}
+
+func TestRead_Restore(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		corrupt  []byte
+		lastGood string
+		expErr   bool
+	}{
+		"restore truncated manifest": {
+			corrupt:  golden.Get(t, "manifest-v3.json")[:100],
+			lastGood: "manifest-v3.json",
+		},
+		"restore empty manifest": {
+			corrupt:  []byte{},
+			lastGood: "manifest-v3.json",
+		},
+		"restore and upgrade older last good copy": {
+			corrupt:  []byte(`{"manifestVersion": "v3"`),
+			lastGood: "manifest-v1.json",
+		},
+		"fail without last good copy": {
+			corrupt: []byte(`{"manifestVersion": "v3"`),
+			expErr:  true,
+		},
+		"fail with invalid last good copy": {
+			corrupt:  []byte(`{"manifestVersion": "v3"`),
+			lastGood: "manifest-v0.json",
+			expErr:   true,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, path := pluginConfig(t, "manifest-v3.json")
+			require.NoError(t, os.WriteFile(path, test.corrupt, 0o644))
+
+			if test.lastGood != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(path), manifest.LastGoodFilename), golden.Get(t, test.lastGood), 0o644))
+			}
+
+			man, err := manifest.Read(cfg, name)
+
+			if test.expErr {
+				// The error describes why the manifest file is corrupted.
+				var syntaxErr *json.SyntaxError
+				require.ErrorAs(t, err, &syntaxErr)
+
+				act, err := os.ReadFile(path)
+				require.NoError(t, err)
+				assert.Equal(t, test.corrupt, act, "the manifest file should be unchanged")
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, expectedManifestVersion(t, latestVersion), man.ManifestVersion())
+			assert.Equal(t, name, man.PluginName())
+
+			act, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.NoError(t, manifest.Validate(act))
+
+			// The corrupted manifest is kept so it can be inspected.
+			backup, err := os.ReadFile(filepath.Join(filepath.Dir(path), manifest.CorruptFilename))
+			require.NoError(t, err)
+			assert.Equal(t, test.corrupt, backup)
+		})
+	}
+}
+
+func TestRead_InvalidIsntRestored(t *testing.T) {
+	t.Parallel()
+
+	const payload = `"pluginName": "go-enum", "packageName": "github.com/abice/go-enum", "gitRepository": "https://github.com/abice/go-enum.git"`
+
+	tests := map[string]struct {
+		invalid string
+		expErr  error
+	}{
+		"missing properties": {
+			invalid: `{"manifestVersion": "v3", "manifestPayload": {}}`,
+			expErr:  manifest.ErrInvalidManifest,
+		},
+		"misspelled property": {
+			invalid: `{"manifestVersion": "v3", "manifestPayload": {` + payload + `, "biuld": {}}}`,
+			expErr:  manifest.ErrInvalidManifest,
+		},
+		"duplicate binary": {
+			invalid: `{"manifestVersion": "v3", "manifestPayload": {` + payload + `, "packages": [{"packageName": "github.com/abice/go-enum/cmd/go-enum"}]}}`,
+			expErr:  goinstall.ErrDuplicateBinary,
+		},
+		"invalid build options": {
+			invalid: `{"manifestVersion": "v3", "manifestPayload": {` + payload + `, "build": {"binaryName": "../enum"}}}`,
+			expErr:  goinstall.ErrInvalidBuildOptions,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, path := pluginConfig(t, "manifest-v3.json")
+			dir := filepath.Dir(path)
+
+			require.NoError(t, os.WriteFile(path, []byte(test.invalid), 0o644))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, manifest.LastGoodFilename), golden.Get(t, "manifest-v3.json"), 0o644))
+
+			// A hand-edited manifest with a mistake is reported rather than
+			// replaced by the last good copy.
+			_, err := manifest.Read(cfg, name)
+			require.ErrorIs(t, err, test.expErr)
+
+			act, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, test.invalid, string(act))
+			assert.NoFileExists(t, filepath.Join(dir, manifest.CorruptFilename))
+		})
+	}
+}
+
+func TestRead_UnsupportedVersionIsntRestored(t *testing.T) {
+	t.Parallel()
+
+	cfg, path := pluginConfig(t, "manifest-v0.json")
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(path), manifest.LastGoodFilename), golden.Get(t, "manifest-v3.json"), 0o644))
+
+	_, err := manifest.Read(cfg, name)
+	require.ErrorIs(t, err, manifest.ErrUnsupportedVersion)
+
+	act, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, golden.Get(t, "manifest-v0.json"), act)
+}
+
+func TestManifest_Write_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	const (
+		writers = 10
+		readers = 10
+	)
+
+	cfg, _ := pluginConfig(t, "manifest-v3.json")
+	man := manifest.New(name, pkg, packageURL(t)).WithGitReference(tagReference(t))
+
+	var wg sync.WaitGroup
+
+	for i := range writers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			md := &pkgsite.Metadata{Package: pkg, Version: fmt.Sprintf("v0.6.%d", i)}
+			assert.NoError(t, man.WithMetadata(md).Write(cfg, name))
+		}()
+	}
+
+	for range readers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := manifest.Read(cfg, name)
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	act, err := manifest.Read(cfg, name)
+	require.NoError(t, err)
+	assert.Equal(t, pkg, act.Metadata().Package)
+}
@@ -0,0 +1,25 @@
+//go:build ignore
+
+// gen_schema writes the manifest's JSON Schema to the root of the
+// repository, where it's published for editors and CI jobs.
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/selesy/asdf-go-install/internal/manifest"
+)
+
+func main() {
+	data, err := json.MarshalIndent(manifest.Schema(), "", "    ")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join("..", "..", manifest.SchemaFilename), append(data, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,22 +1,32 @@
 package manifest
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/url"
-	"os"
 	"path/filepath"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-playground/validator/v10"
+	"github.com/lmittmann/tint"
 
 	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/goinstall"
+	"github.com/selesy/asdf-go-install/internal/lockedfile"
+	"github.com/selesy/asdf-go-install/internal/pkgsite"
 	"github.com/selesy/asdf-go-install/internal/plugin"
 )
 
 const (
 	ManifestFilename  = "manifest.json"
 	manifestVersionV1 = "v1"
+	manifestVersionV2 = "v2"
+	manifestVersionV3 = "v3"
+
+	manifestVersionCurrent = manifestVersionV3
 )
 
 var (
@@ -25,10 +35,19 @@ var (
 )
 
 type payload struct {
-	PluginName    string              `json:"pluginName" validate:"required"`
-	PackageName   string              `json:"packageName" validate:"required"`
-	GitRepository *url.URL            `json:"gitRepository" validate:"required"`
-	GitReference  *plumbing.Reference `json:"gitReference"`
+	PluginName     string              `json:"pluginName" jsonschema:"required,minLength=1"`
+	PackageName    string              `json:"packageName" jsonschema:"required,minLength=1"`
+	GitRepository  *url.URL            `json:"gitRepository" jsonschema:"required,minLength=1"`
+	GitReference   *plumbing.Reference `json:"gitReference"`
+	ModulePath     string              `json:"modulePath,omitempty"`
+	PackageSubpath string              `json:"packageSubpath,omitempty"`
+	Metadata       *pkgsite.Metadata   `json:"metadata,omitempty"`
+
+	// Build was added in v2 of the manifest.
+	Build *goinstall.BuildOptions `json:"build,omitempty"`
+
+	// Packages was added in v3 of the manifest.
+	Packages []*goinstall.Package `json:"packages,omitempty"`
 }
 
 // MarshalJSON implements json.Marshaler.
@@ -52,7 +71,7 @@ func (p *payload) MarshalJSON() ([]byte, error) {
 	type alias payload
 
 	return json.Marshal(&struct {
-		GitRepository string        `json:"gitRepository" validate:"required"`
+		GitRepository string        `json:"gitRepository"`
 		GitReference  *gitReference `json:"gitReference,omitempty"`
 		*alias
 	}{
@@ -66,7 +85,7 @@ func (p *payload) MarshalJSON() ([]byte, error) {
 func (p *payload) UnmarshalJSON(data []byte) error {
 	type alias payload
 	clone := &struct {
-		GitRepository string `json:"gitRepository" validate:"required"`
+		GitRepository string `json:"gitRepository"`
 		GitReference  struct {
 			Name string
 			Hash string
@@ -95,8 +114,8 @@ func (p *payload) UnmarshalJSON(data []byte) error {
 var _ json.Marshaler = (*manifest)(nil)
 
 type manifest struct {
-	ManifestVersion *semver.Version `json:"manifestVersion" validate:"required"`
-	Payload         *payload        `json:"manifestPayload" validate:"required"`
+	ManifestVersion *semver.Version `json:"manifestVersion" jsonschema:"required"`
+	Payload         *payload        `json:"manifestPayload" jsonschema:"required"`
 }
 
 // MarshalJSON implements json.Marshaler.
@@ -118,9 +137,10 @@ type Manifest struct {
 	manifest *manifest
 }
 
-// New creates an immutable instance of a Manifest.
+// New creates an immutable instance of a Manifest using the latest
+// manifest version.
 func New(name string, pkg string, repo *url.URL) *Manifest {
-	vers := semver.MustParse(manifestVersionV1)
+	vers := semver.MustParse(manifestVersionCurrent)
 
 	return &Manifest{
 		manifest: &manifest{
@@ -134,28 +154,104 @@ func New(name string, pkg string, repo *url.URL) *Manifest {
 	}
 }
 
+// RepositoryResolver returns the URL of the Go package's Git repository
+// (e.g. goget.Repository or pkgsite.Repository.)
+type RepositoryResolver func(ctx context.Context, cfg *config.Config, pkg string) (*url.URL, error)
+
+// Discover creates a Manifest for the Go package using the repository
+// URL returned by the first RepositoryResolver that succeeds.  The
+// resolvers are tried in order so that, for example, the package's
+// go-get meta tags can be preferred over pkg.go.dev, which might not
+// have indexed the module.
+func Discover(ctx context.Context, cfg *config.Config, name string, pkg string, resolvers ...RepositoryResolver) (*Manifest, error) {
+	var errs []error
+
+	for _, resolve := range resolvers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		repo, err := resolve(ctx, cfg, pkg)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		if repo != nil {
+			return New(name, pkg, repo), nil
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrRepositoryNotFound, pkg)
+	}
+
+	return nil, fmt.Errorf("%w: %s: %w", ErrRepositoryNotFound, pkg, errors.Join(errs...))
+}
+
 // Read opens the manifest file in the plugin's top-level directory and
-// decodes the JSON into a Manifest.
+// decodes the JSON into a Manifest.  Manifests written with an older
+// version are upgraded to the latest version and written back, with the
+// original file kept as a backup (e.g. manifest.json.v1.bak.)
+// ErrUnsupportedVersion is returned if the manifest's version can't be
+// upgraded and ErrInvalidManifest if it isn't valid (see Validate.)
+//
+// A manifest file that can't be decoded is replaced by the last good
+// copy that was written (see LastGoodFilename) if one exists.
 func Read(cfg *config.Config, pluginName string) (*Manifest, error) {
-	data, err := os.ReadFile(filepath.Join(cfg.Env().DataDir(), "plugins", pluginName, ManifestFilename))
+	path := filepath.Join(cfg.Env().DataDir(), "plugins", pluginName, ManifestFilename)
+
+	data, err := lockedfile.Read(path)
 	if err != nil {
 		return nil, err
 	}
 
-	var man manifest
+	man, vers, err := decode(data)
+	if corrupted(err) {
+		cause := err
 
-	if err := json.Unmarshal(data, &man); err != nil {
-		return nil, err
+		data, err = restore(path, cause)
+		if err == nil {
+			cfg.Log().Warn(
+				"Restored the last good copy of the corrupted manifest",
+				slog.String("path", path),
+				slog.String("backup", corruptPath(path)),
+				tint.Err(cause),
+			)
+
+			man, vers, err = decode(data)
+		}
 	}
 
-	validate := validator.New(validator.WithRequiredStructEnabled())
-	if err := validate.Struct(man); err != nil {
-		return nil, err
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pluginName, err)
 	}
 
-	return &Manifest{
-		manifest: &man,
-	}, nil
+	if vers != manifestVersionCurrent {
+		upgrade(cfg, path, vers, data, man.manifest)
+	}
+
+	return man, nil
+}
+
+// Binaries returns the names of the binaries installed by the plugin's
+// Packages.
+func (m *Manifest) Binaries() []string {
+	pkgs := m.Packages()
+	names := make([]string, 0, len(pkgs))
+
+	for _, pkg := range pkgs {
+		names = append(names, goinstall.BinaryName(pkg.Path, pkg.Build))
+	}
+
+	return names
+}
+
+// BuildOptions returns the settings used to build the plugin's tool or
+// nil if the tool is built with the go command's defaults.
+func (m *Manifest) BuildOptions() *goinstall.BuildOptions {
+	return m.manifest.Payload.Build
 }
 
 // GitReferenece returns the plugin's Git reference or nil if no Git
@@ -174,6 +270,35 @@ func (m *Manifest) ManifestVersion() *semver.Version {
 	return m.manifest.ManifestVersion
 }
 
+// Metadata returns the package information that was scraped from
+// pkg.go.dev when the plugin was added or nil if it's unavailable.
+func (m *Manifest) Metadata() *pkgsite.Metadata {
+	return m.manifest.Payload.Metadata
+}
+
+// ModulePath returns the path of the module that provides the plugin's
+// package or an empty string if the module wasn't resolved.
+func (m *Manifest) ModulePath() string {
+	return m.manifest.Payload.ModulePath
+}
+
+// PackageSubpath returns the directory of the plugin's package relative
+// to the root of its module (see ModulePath.)  An empty string indicates
+// that the package is the module's root package.
+func (m *Manifest) PackageSubpath() string {
+	return m.manifest.Payload.PackageSubpath
+}
+
+// Packages returns the plugin's package (see PluginPackage and
+// BuildOptions) followed by the additional packages that are installed
+// from the same module at the same version.
+func (m *Manifest) Packages() []*goinstall.Package {
+	return append([]*goinstall.Package{{
+		Path:  m.manifest.Payload.PackageName,
+		Build: m.manifest.Payload.Build,
+	}}, m.manifest.Payload.Packages...)
+}
+
 // PluginName returns the plugin's name.
 func (m *Manifest) PluginName() string {
 	return m.manifest.Payload.PluginName
@@ -184,29 +309,83 @@ func (m *Manifest) PluginPackage() string {
 	return m.manifest.Payload.PackageName
 }
 
+// WithBuildOptions creates a clone of the Manifest that includes the
+// settings used to build the plugin's tool.
+func (m *Manifest) WithBuildOptions(opts *goinstall.BuildOptions) *Manifest {
+	clone := m.clone()
+	clone.manifest.Payload.Build = opts
+
+	return clone
+}
+
 // WithGitReference creates a clone of the Manifest that includes the
 // provided Git reference.
 func (m *Manifest) WithGitReference(ref *plumbing.Reference) *Manifest {
+	clone := m.clone()
+	clone.manifest.Payload.GitReference = ref
+
+	return clone
+}
+
+// WithMetadata creates a clone of the Manifest that includes the
+// package information scraped from pkg.go.dev.
+func (m *Manifest) WithMetadata(md *pkgsite.Metadata) *Manifest {
+	clone := m.clone()
+	clone.manifest.Payload.Metadata = md
+
+	return clone
+}
+
+// WithPackages creates a clone of the Manifest that includes the
+// additional packages that are installed along with the plugin's
+// package.  The packages must belong to the plugin's module (see
+// goinstall.Package.)
+func (m *Manifest) WithPackages(pkgs ...*goinstall.Package) *Manifest {
+	clone := m.clone()
+	clone.manifest.Payload.Packages = pkgs
+
+	return clone
+}
+
+// WithModule creates a clone of the Manifest that includes the path of
+// the module that provides the plugin's package and the package's
+// directory relative to the module root.
+func (m *Manifest) WithModule(mod string, subpath string) *Manifest {
+	clone := m.clone()
+	clone.manifest.Payload.ModulePath = mod
+	clone.manifest.Payload.PackageSubpath = subpath
+
+	return clone
+}
+
+func (m *Manifest) clone() *Manifest {
+	payload := *m.manifest.Payload
+
 	return &Manifest{
 		manifest: &manifest{
 			ManifestVersion: m.manifest.ManifestVersion,
-			Payload: &payload{
-				PluginName:    m.manifest.Payload.PluginName,
-				PackageName:   m.manifest.Payload.PackageName,
-				GitRepository: m.manifest.Payload.GitRepository,
-				GitReference:  ref,
-			},
+			Payload:         &payload,
 		},
 	}
 }
 
 // Write encodes the Manifest to JSON and creates the relevant file in
-// the plugin's top-level directory.
+// the plugin's top-level directory.  The file is replaced atomically
+// while holding its advisory lock, so concurrent asdf processes never
+// read a partially written manifest, and is also saved as the
+// LastGoodFilename.
+//
+// The Manifest is validated as it is by Read and nothing is written if
+// it's invalid (see Validate.)
 func (m *Manifest) Write(cfg *config.Config, pluginName string) error {
 	data, err := json.Marshal(m.manifest)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(filepath.Join(plugin.Path(cfg, pluginName), ManifestFilename), data, 0o644)
+	if _, _, err := decode(data); err != nil {
+		return fmt.Errorf("%s: %w", pluginName, err)
+	}
+
+	return write(filepath.Join(plugin.Path(cfg, pluginName), ManifestFilename), data)
 }
@@ -1,6 +1,8 @@
 package manifest_test
 
 import (
+	"context"
+	"errors"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -8,8 +10,11 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/selesy/asdf-go-install/internal/config"
 	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/goinstall"
 	"github.com/selesy/asdf-go-install/internal/manifest"
+	"github.com/selesy/asdf-go-install/internal/pkgsite"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gotest.tools/v3/golden"
@@ -18,31 +23,190 @@ import (
 const (
 	name = "go-enum"
 	pkg  = "github.com/abice/" + name
+
+	latestVersion = "v3"
 )
 
 func TestNew(t *testing.T) {
 	t.Parallel()
 
 	man := manifest.New(name, pkg, packageURL(t))
-	assert.Equal(t, expectedManifestVersion(t), man.ManifestVersion())
+	assert.Equal(t, expectedManifestVersion(t, latestVersion), man.ManifestVersion())
 	assert.Equal(t, name, man.PluginName())
 	assert.Equal(t, pkg, man.PluginPackage())
 	assert.Equal(t, packageURL(t), man.GitRepository())
 	assert.Nil(t, man.GitReference())
+	assert.Nil(t, man.BuildOptions())
+}
+
+func TestDiscover(t *testing.T) {
+	t.Parallel()
+
+	errResolve := errors.New("repository not resolved")
+
+	resolved := func(context.Context, *config.Config, string) (*url.URL, error) {
+		return packageURL(t), nil
+	}
+
+	unresolved := func(context.Context, *config.Config, string) (*url.URL, error) {
+		return nil, nil
+	}
+
+	failing := func(context.Context, *config.Config, string) (*url.URL, error) {
+		return nil, errResolve
+	}
+
+	tests := map[string]struct {
+		resolvers []manifest.RepositoryResolver
+		expErr    []error
+	}{
+		"first resolver succeeds": {
+			resolvers: []manifest.RepositoryResolver{resolved, failing},
+		},
+		"falls back after failure": {
+			resolvers: []manifest.RepositoryResolver{failing, unresolved, resolved},
+		},
+		"all resolvers fail": {
+			resolvers: []manifest.RepositoryResolver{failing, unresolved},
+			expErr:    []error{manifest.ErrRepositoryNotFound, errResolve},
+		},
+		"without resolvers": {
+			expErr: []error{manifest.ErrRepositoryNotFound},
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+
+			man, err := manifest.Discover(context.Background(), cfg, name, pkg, test.resolvers...)
+
+			for _, expErr := range test.expErr {
+				require.ErrorIs(t, err, expErr)
+			}
+
+			if len(test.expErr) > 0 {
+				assert.Nil(t, man)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, pkg, man.PluginPackage())
+			assert.Equal(t, packageURL(t), man.GitRepository())
+		})
+	}
 }
 
 func TestRead(t *testing.T) {
 	t.Parallel()
 
-	cfg, _, _ := configtest.NewConfig(t, []string{"ASDF_DATA_DIR=testdata"}, []string{})
+	tests := map[string]struct {
+		fixture     string
+		expBuild    *goinstall.BuildOptions
+		expPackages []*goinstall.Package
+		expErr      error
+	}{
+		"v1": {
+			fixture: "manifest-v1.json",
+		},
+		"v2": {
+			fixture:  "manifest-v2.json",
+			expBuild: buildOptions(),
+		},
+		"v3": {
+			fixture:     "manifest-v3.json",
+			expBuild:    buildOptions(),
+			expPackages: packages(),
+		},
+		"unsupported version": {
+			fixture: "manifest-v0.json",
+			expErr:  manifest.ErrUnsupportedVersion,
+		},
+	}
 
-	man, err := manifest.Read(cfg, name)
-	require.NoError(t, err)
-	assert.Equal(t, expectedManifestVersion(t), man.ManifestVersion())
-	assert.Equal(t, name, man.PluginName())
-	assert.Equal(t, pkg, man.PluginPackage())
-	assert.Equal(t, packageURL(t), man.GitRepository())
-	assert.Equal(t, tagReference(t), man.GitReference())
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _ := pluginConfig(t, test.fixture)
+
+			man, err := manifest.Read(cfg, name)
+			require.ErrorIs(t, err, test.expErr)
+
+			if test.expErr != nil {
+				return
+			}
+
+			assert.Equal(t, expectedManifestVersion(t, latestVersion), man.ManifestVersion())
+			assert.Equal(t, name, man.PluginName())
+			assert.Equal(t, pkg, man.PluginPackage())
+			assert.Equal(t, packageURL(t), man.GitRepository())
+			assert.Equal(t, tagReference(t), man.GitReference())
+			assert.Equal(t, test.expBuild, man.BuildOptions())
+			assert.Equal(t, append([]*goinstall.Package{{Path: pkg, Build: test.expBuild}}, test.expPackages...), man.Packages())
+		})
+	}
+}
+
+func TestManifest_Write_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		man    *manifest.Manifest
+		expErr error
+	}{
+		"fail with duplicate binary": {
+			man:    manifest.New(name, pkg, packageURL(t)).WithPackages(&goinstall.Package{Path: pkg + "/cmd/" + name}),
+			expErr: goinstall.ErrDuplicateBinary,
+		},
+		"fail with invalid build options": {
+			man:    manifest.New(name, pkg, packageURL(t)).WithBuildOptions(&goinstall.BuildOptions{BinaryName: "../enum"}),
+			expErr: goinstall.ErrInvalidBuildOptions,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			t.Parallel()
+
+			dataDir := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "plugins", name), 0o755))
+
+			cfg, _, _ := configtest.NewConfig(t, []string{"ASDF_DATA_DIR=" + dataDir}, []string{})
+
+			err := test.man.Write(cfg, name)
+			require.ErrorIs(t, err, manifest.ErrInvalidManifest)
+			require.ErrorIs(t, err, test.expErr)
+
+			assert.NoFileExists(t, filepath.Join(dataDir, "plugins", name, manifest.ManifestFilename))
+			assert.NoFileExists(t, filepath.Join(dataDir, "plugins", name, manifest.LastGoodFilename))
+		})
+	}
+}
+
+func TestManifest_WithBuildOptions(t *testing.T) {
+	t.Parallel()
+
+	man1 := manifest.New(name, pkg, packageURL(t))
+	man2 := man1.WithBuildOptions(buildOptions())
+
+	assert.Nil(t, man1.BuildOptions())
+	assert.Equal(t, buildOptions(), man2.BuildOptions())
+}
+
+func TestManifest_WithPackages(t *testing.T) {
+	t.Parallel()
+
+	man1 := manifest.New(name, pkg, packageURL(t)).WithBuildOptions(buildOptions())
+	man2 := man1.WithPackages(packages()...)
+
+	assert.Equal(t, []*goinstall.Package{{Path: pkg, Build: buildOptions()}}, man1.Packages())
+	assert.Equal(t, []string{"enum"}, man1.Binaries())
+	assert.Equal(t, append([]*goinstall.Package{{Path: pkg, Build: buildOptions()}}, packages()...), man2.Packages())
+	assert.Equal(t, []string{"enum", "enumer", "enumlint"}, man2.Binaries())
 }
 
 func TestManifest_WithGitReference(t *testing.T) {
@@ -55,6 +219,60 @@ func TestManifest_WithGitReference(t *testing.T) {
 	assert.Equal(t, tagReference(t), man2.GitReference())
 }
 
+func TestManifest_WithModule(t *testing.T) {
+	t.Parallel()
+
+	dataDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "plugins", name), 0o755))
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"ASDF_DATA_DIR=" + dataDir}, []string{})
+
+	man1 := manifest.New(name, pkg+"/cmd/"+name, packageURL(t)).WithGitReference(tagReference(t))
+	man2 := man1.WithModule(pkg, "cmd/"+name)
+
+	assert.Empty(t, man1.ModulePath())
+	assert.Empty(t, man1.PackageSubpath())
+	assert.Equal(t, pkg, man2.ModulePath())
+	assert.Equal(t, "cmd/"+name, man2.PackageSubpath())
+	assert.Equal(t, tagReference(t), man2.GitReference())
+
+	require.NoError(t, man2.Write(cfg, name))
+
+	man3, err := manifest.Read(cfg, name)
+	require.NoError(t, err)
+	assert.Equal(t, pkg, man3.ModulePath())
+	assert.Equal(t, "cmd/"+name, man3.PackageSubpath())
+}
+
+func TestManifest_WithMetadata(t *testing.T) {
+	t.Parallel()
+
+	dataDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "plugins", name), 0o755))
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"ASDF_DATA_DIR=" + dataDir}, []string{})
+
+	md := &pkgsite.Metadata{
+		Package:    pkg,
+		Version:    "v0.6.0",
+		Synopsis:   "An enum generator for go",
+		Licenses:   []string{"MIT"},
+		ImportedBy: 3,
+	}
+
+	man1 := manifest.New(name, pkg, packageURL(t))
+	man2 := man1.WithMetadata(md)
+
+	assert.Nil(t, man1.Metadata())
+	assert.Equal(t, md, man2.Metadata())
+
+	require.NoError(t, man2.Write(cfg, name))
+
+	man3, err := manifest.Read(cfg, name)
+	require.NoError(t, err)
+	assert.Equal(t, md, man3.Metadata())
+}
+
 func TestManifest_Write(t *testing.T) {
 	t.Parallel()
 
@@ -65,20 +283,63 @@ func TestManifest_Write(t *testing.T) {
 
 	man := manifest.New(name, pkg, packageURL(t))
 	man = man.WithGitReference(tagReference(t))
+	man = man.WithBuildOptions(buildOptions())
+	man = man.WithPackages(packages()...)
 
 	require.NoError(t, man.Write(cfg, name))
 
-	exp := golden.Get(t, filepath.Join("plugins", name, manifest.ManifestFilename))
+	exp := golden.Get(t, "manifest-v3.json")
 
 	act, err := os.ReadFile(filepath.Join(dataDir, "plugins", name, manifest.ManifestFilename))
 	require.NoError(t, err)
 	assert.JSONEq(t, string(exp), string(act))
 }
 
-func expectedManifestVersion(t *testing.T) *semver.Version {
+// pluginConfig copies the testdata fixture to the manifest file of a
+// plugin in a temporary data directory and returns the Config along
+// with the path of the manifest file.
+func pluginConfig(t *testing.T, fixture string) (*config.Config, string) {
+	t.Helper()
+
+	dataDir := t.TempDir()
+	path := filepath.Join(dataDir, "plugins", name, manifest.ManifestFilename)
+
+	data, err := os.ReadFile(filepath.Join("testdata", fixture))
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"ASDF_DATA_DIR=" + dataDir}, []string{})
+
+	return cfg, path
+}
+
+func buildOptions() *goinstall.BuildOptions {
+	cgo := false
+
+	return &goinstall.BuildOptions{
+		Tags:         []string{"netgo"},
+		CGOEnabled:   &cgo,
+		GoFlags:      []string{"-mod=mod"},
+		LDFlags:      "-s -w",
+		TrimPath:     true,
+		GoExperiment: []string{"rangefunc"},
+		Env:          map[string]string{"GOAMD64": "v3"},
+		BinaryName:   "enum",
+	}
+}
+
+func packages() []*goinstall.Package {
+	return []*goinstall.Package{
+		{Path: pkg + "/cmd/enumer"},
+		{Path: pkg + "/cmd/enumlint", Build: &goinstall.BuildOptions{TrimPath: true}},
+	}
+}
+
+func expectedManifestVersion(t *testing.T, vers string) *semver.Version {
 	t.Helper()
 
-	expVers, err := semver.NewVersion("v1")
+	expVers, err := semver.NewVersion(vers)
 	require.NoError(t, err)
 
 	return expVers
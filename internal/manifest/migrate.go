@@ -0,0 +1,149 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/lmittmann/tint"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/lockedfile"
+)
+
+// backupSuffix is appended to the manifest file's name, along with the
+// original version, when a manifest is upgraded (e.g.
+// manifest.json.v1.bak.)
+const backupSuffix = ".bak"
+
+// migration upgrades a manifest's JSON document from one version to the
+// next.  The document's manifestVersion is updated by migrate so each
+// migration only changes the fields that differ between the versions.
+type migration struct {
+	from    string
+	to      string
+	migrate func(doc map[string]json.RawMessage) error
+}
+
+// migrations upgrade manifests, one version at a time, to
+// manifestVersionCurrent.  When the schema changes, add a migration
+// from the previous version and a manifest-<version>.json file (and its
+// upgraded .golden.json) to the testdata.
+var migrations = []migration{
+	{from: manifestVersionV1, to: manifestVersionV2, migrate: migrateV1ToV2},
+	{from: manifestVersionV2, to: manifestVersionV3, migrate: migrateV2ToV3},
+}
+
+// migrateV1ToV2 is a no-op since v2 only adds the optional build
+// options.
+func migrateV1ToV2(map[string]json.RawMessage) error {
+	return nil
+}
+
+// migrateV2ToV3 is a no-op since v3 only adds the optional additional
+// packages.
+func migrateV2ToV3(map[string]json.RawMessage) error {
+	return nil
+}
+
+// migrate applies the migrations needed to upgrade the manifest's JSON
+// to manifestVersionCurrent and returns the upgraded JSON along with
+// the manifest's original version.  ErrUnsupportedVersion is returned if
+// no migration exists for the manifest's version.
+func migrate(data []byte) ([]byte, string, error) {
+	var doc map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, "", err
+	}
+
+	var orig string
+
+	if err := json.Unmarshal(doc["manifestVersion"], &orig); err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrUnsupportedVersion, doc["manifestVersion"])
+	}
+
+	if orig == manifestVersionCurrent {
+		return data, orig, nil
+	}
+
+	vers := orig
+
+	for vers != manifestVersionCurrent {
+		mig, ok := findMigration(vers)
+		if !ok {
+			return nil, "", fmt.Errorf("%w: %s", ErrUnsupportedVersion, orig)
+		}
+
+		if err := mig.migrate(doc); err != nil {
+			return nil, "", fmt.Errorf("migrating manifest from %s to %s: %w", mig.from, mig.to, err)
+		}
+
+		vers = mig.to
+
+		raw, err := json.Marshal(vers)
+		if err != nil {
+			return nil, "", err
+		}
+
+		doc["manifestVersion"] = raw
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, orig, nil
+}
+
+func findMigration(vers string) (migration, bool) {
+	for _, mig := range migrations {
+		if mig.from == vers {
+			return mig, true
+		}
+	}
+
+	return migration{}, false
+}
+
+// upgrade replaces the manifest file at path with the upgraded manifest
+// after saving the original data next to it.  The manifest file is left
+// unchanged if another process has replaced it since it was read.
+// Failures are logged since the manifest is upgraded again the next
+// time it's read.
+func upgrade(cfg *config.Config, path string, orig string, data []byte, man *manifest) {
+	log := cfg.Log().With(
+		slog.String("path", path),
+		slog.String("from", orig),
+		slog.String("to", manifestVersionCurrent),
+	)
+
+	upgraded, err := json.Marshal(man)
+	if err == nil {
+		err = lockedfile.Update(path, 0o644, func(cur []byte) ([]byte, error) {
+			if !bytes.Equal(cur, data) {
+				return cur, nil
+			}
+
+			if err := lockedfile.WriteUnlocked(path+"."+orig+backupSuffix, data, 0o644); err != nil {
+				return nil, err
+			}
+
+			if err := lockedfile.WriteUnlocked(lastGoodPath(path), upgraded, 0o644); err != nil {
+				return nil, err
+			}
+
+			return upgraded, nil
+		})
+	}
+
+	if err != nil {
+		log.Warn("Failed to upgrade manifest", tint.Err(err))
+
+		return
+	}
+
+	log.Info("Upgraded manifest")
+}
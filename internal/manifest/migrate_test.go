@@ -0,0 +1,85 @@
+package manifest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gotest.tools/v3/golden"
+
+	"github.com/selesy/asdf-go-install/internal/manifest"
+)
+
+func TestRead_Migrate(t *testing.T) {
+	t.Parallel()
+
+	// Every historical manifest version must be listed with the golden
+	// file of its upgraded manifest.
+	tests := map[string]struct {
+		fixture string
+		golden  string
+		backup  bool
+	}{
+		"v1": {fixture: "manifest-v1.json", golden: "manifest-v1.golden.json", backup: true},
+		"v2": {fixture: "manifest-v2.json", golden: "manifest-v2.golden.json", backup: true},
+		"v3": {fixture: "manifest-v3.json", golden: "manifest-v3.json"},
+	}
+
+	for vers, test := range tests {
+		t.Run(vers, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, path := pluginConfig(t, test.fixture)
+
+			man, err := manifest.Read(cfg, name)
+			require.NoError(t, err)
+			assert.Equal(t, expectedManifestVersion(t, latestVersion), man.ManifestVersion())
+
+			act, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(golden.Get(t, test.golden)), string(act))
+
+			backup, err := os.ReadFile(path + "." + vers + ".bak")
+			if !test.backup {
+				require.ErrorIs(t, err, os.ErrNotExist)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, golden.Get(t, test.fixture), backup)
+
+			// The upgraded manifest is read without being upgraded again.
+			require.NoError(t, os.Remove(path+"."+vers+".bak"))
+
+			_, err = manifest.Read(cfg, name)
+			require.NoError(t, err)
+			assert.NoFileExists(t, path+"."+vers+".bak")
+		})
+	}
+}
+
+func TestRead_MigrateUnwritable(t *testing.T) {
+	t.Parallel()
+
+	cfg, path := pluginConfig(t, "manifest-v1.json")
+	require.NoError(t, os.Chmod(filepath.Dir(path), 0o555))
+	t.Cleanup(func() { _ = os.Chmod(filepath.Dir(path), 0o755) })
+
+	if f, err := os.CreateTemp(filepath.Dir(path), "probe"); err == nil {
+		_ = f.Close()
+		t.Skip("directory permissions aren't enforced")
+	}
+
+	// The manifest is still upgraded in memory when the file can't be
+	// written back.
+	man, err := manifest.Read(cfg, name)
+	require.NoError(t, err)
+	assert.Equal(t, expectedManifestVersion(t, latestVersion), man.ManifestVersion())
+
+	act, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, golden.Get(t, "manifest-v1.json"), act)
+}
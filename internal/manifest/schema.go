@@ -0,0 +1,166 @@
+package manifest
+
+//go:generate go run gen_schema.go
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/invopop/jsonschema"
+	jsonschemav6 "github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/selesy/asdf-go-install/internal/goinstall"
+)
+
+const (
+	// SchemaFilename is the name of the published JSON Schema in the
+	// root of the repository.
+	SchemaFilename = "manifest.schema.json"
+
+	// SchemaID is the URL of the published JSON Schema, which editors
+	// and CI jobs can use to check manifest files.
+	SchemaID = "https://raw.githubusercontent.com/selesy/asdf-go-install/main/" + SchemaFilename
+)
+
+// schema reflects the manifest's types with the required properties
+// taken from their jsonschema tags.  The objects generated for structs
+// don't allow additional properties so that misspelled properties are
+// reported.
+var schema = sync.OnceValue(func() *jsonschema.Schema {
+	r := &jsonschema.Reflector{
+		Anonymous:                  true,
+		DoNotReference:             true,
+		RequiredFromJSONSchemaTags: true,
+		Mapper: func(t reflect.Type) *jsonschema.Schema {
+			switch t {
+			case reflect.TypeFor[semver.Version]():
+				return &jsonschema.Schema{
+					Type: "string",
+					Enum: manifestVersions(),
+				}
+			case reflect.TypeFor[plumbing.Reference]():
+				props := jsonschema.NewProperties()
+				props.Set("name", &jsonschema.Schema{Type: "string"})
+				props.Set("hash", &jsonschema.Schema{Type: "string", Pattern: "^[0-9a-f]{40}([0-9a-f]{24})?$"})
+
+				return &jsonschema.Schema{
+					Type:                 "object",
+					Properties:           props,
+					Required:             []string{"name", "hash"},
+					AdditionalProperties: jsonschema.FalseSchema,
+				}
+			default:
+				return nil
+			}
+		},
+	}
+
+	s := r.Reflect(&manifest{})
+	s.ID = SchemaID
+	s.Title = "asdf-go-install plugin manifest"
+	s.Description = "The " + ManifestFilename + " file that describes the Go package(s) installed by an asdf-go-install plugin."
+
+	return s
+})
+
+// validator compiles the Schema so that manifest files can be checked
+// against it.
+var validator = sync.OnceValues(func() (*jsonschemav6.Schema, error) {
+	data, err := json.Marshal(Schema())
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := jsonschemav6.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	c := jsonschemav6.NewCompiler()
+
+	if err := c.AddResource(SchemaID, doc); err != nil {
+		return nil, err
+	}
+
+	return c.Compile(SchemaID)
+})
+
+// Schema returns the JSON Schema of the latest manifest version that's
+// generated from the manifest's types.  The published copy (see
+// SchemaFilename) is regenerated with "go generate".
+//
+// Older manifest versions are accepted too, since each version only
+// adds optional properties and manifests are upgraded when they're
+// read, so that manifest files that haven't been upgraded yet can still
+// be checked.
+func Schema() *jsonschema.Schema {
+	return schema()
+}
+
+// manifestVersions returns the manifest versions that can be read: the
+// version each migration upgrades from and manifestVersionCurrent.
+func manifestVersions() []any {
+	vers := make([]any, 0, len(migrations)+1)
+
+	for _, mig := range migrations {
+		vers = append(vers, mig.from)
+	}
+
+	return append(vers, manifestVersionCurrent)
+}
+
+// Validate checks the contents of a manifest file, as the
+// validate-manifest extension command does, without reading it into a
+// plugin.  Manifests written with an older version are upgraded before
+// they're checked against the JSON Schema, which reports each problem
+// with the JSON Pointer of the offending value (see
+// jsonschema.ValidationError in github.com/santhosh-tekuri/jsonschema.)
+func Validate(data []byte) error {
+	_, _, err := decode(data)
+
+	return err
+}
+
+// decode upgrades and validates the manifest file's contents and returns
+// the Manifest along with the file's original version.
+func decode(data []byte) (*Manifest, string, error) {
+	migrated, vers, err := migrate(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s, err := validator()
+	if err != nil {
+		return nil, "", err
+	}
+
+	doc, err := jsonschemav6.UnmarshalJSON(bytes.NewReader(migrated))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.Validate(doc); err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidManifest, err)
+	}
+
+	var man manifest
+
+	if err := json.Unmarshal(migrated, &man); err != nil {
+		return nil, "", err
+	}
+
+	res := &Manifest{
+		manifest: &man,
+	}
+
+	if err := goinstall.ValidatePackages(res.Packages()); err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidManifest, err)
+	}
+
+	return res, vers, nil
+}
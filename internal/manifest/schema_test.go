@@ -0,0 +1,130 @@
+package manifest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gotest.tools/v3/golden"
+
+	"github.com/selesy/asdf-go-install/internal/goinstall"
+	"github.com/selesy/asdf-go-install/internal/manifest"
+)
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+
+	act, err := json.Marshal(manifest.Schema())
+	require.NoError(t, err)
+
+	// The published schema is regenerated with "go generate".
+	exp, err := os.ReadFile(filepath.Join("..", "..", manifest.SchemaFilename))
+	require.NoError(t, err)
+	assert.JSONEq(t, string(exp), string(act))
+}
+
+func TestSchema_Versions(t *testing.T) {
+	t.Parallel()
+
+	// Editors and CI jobs check manifest files against the published
+	// schema without upgrading them first.
+	s, err := jsonschema.NewCompiler().Compile(filepath.Join("..", "..", manifest.SchemaFilename))
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		file   string
+		expErr bool
+	}{
+		"pass with v1 manifest":         {file: "manifest-v1.json"},
+		"pass with v2 manifest":         {file: "manifest-v2.json"},
+		"pass with v3 manifest":         {file: "manifest-v3.json"},
+		"fail with unsupported version": {file: "manifest-v0.json", expErr: true},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			t.Parallel()
+
+			doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(golden.Get(t, test.file)))
+			require.NoError(t, err)
+
+			err = s.Validate(doc)
+			if test.expErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		doc    string
+		expErr error
+		expMsg []string
+	}{
+		"pass with v1 manifest": {
+			doc: string(golden.Get(t, "manifest-v1.json")),
+		},
+		"pass with v3 manifest": {
+			doc: string(golden.Get(t, "manifest-v3.json")),
+		},
+		"fail with unsupported version": {
+			doc:    string(golden.Get(t, "manifest-v0.json")),
+			expErr: manifest.ErrUnsupportedVersion,
+		},
+		"fail without payload": {
+			doc:    `{"manifestVersion": "v3"}`,
+			expErr: manifest.ErrInvalidManifest,
+			expMsg: []string{"at '': missing property 'manifestPayload'"},
+		},
+		"fail with missing and empty properties": {
+			doc:    `{"manifestVersion": "v3", "manifestPayload": {"pluginName": "", "gitRepository": "https://github.com/abice/go-enum.git"}}`,
+			expErr: manifest.ErrInvalidManifest,
+			expMsg: []string{
+				"at '/manifestPayload': missing property 'packageName'",
+				"at '/manifestPayload/pluginName': minLength: got 0, want 1",
+			},
+		},
+		"fail with misspelled build option": {
+			doc:    `{"manifestVersion": "v3", "manifestPayload": {"pluginName": "go-enum", "packageName": "github.com/abice/go-enum", "gitRepository": "https://github.com/abice/go-enum.git", "build": {"trimpath": true}}}`,
+			expErr: manifest.ErrInvalidManifest,
+			expMsg: []string{"at '/manifestPayload/build': additional properties 'trimpath' not allowed"},
+		},
+		"fail with wrong package type": {
+			doc:    `{"manifestVersion": "v3", "manifestPayload": {"pluginName": "go-enum", "packageName": "github.com/abice/go-enum", "gitRepository": "https://github.com/abice/go-enum.git", "packages": ["github.com/abice/go-enum/cmd/enumer"]}}`,
+			expErr: manifest.ErrInvalidManifest,
+			expMsg: []string{"at '/manifestPayload/packages/0': got string, want object"},
+		},
+		"fail with duplicate binary": {
+			doc:    `{"manifestVersion": "v3", "manifestPayload": {"pluginName": "go-enum", "packageName": "github.com/abice/go-enum", "gitRepository": "https://github.com/abice/go-enum.git", "packages": [{"packageName": "github.com/abice/go-enum/cmd/go-enum"}]}}`,
+			expErr: goinstall.ErrDuplicateBinary,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := manifest.Validate([]byte(test.doc))
+			require.ErrorIs(t, err, test.expErr)
+
+			for _, msg := range test.expMsg {
+				var verr *jsonschema.ValidationError
+
+				require.ErrorAs(t, err, &verr)
+				assert.Contains(t, err.Error(), msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package modcache
+
+import "errors"
+
+// ErrNoModCache is returned when the location of the Go module cache
+// can't be determined because GOMODCACHE, GOPATH and HOME are all unset.
+var ErrNoModCache = errors.New("the Go module cache location is unknown (set GOMODCACHE)")
+
+// ErrNotCached is matched (using errors.Is) by every NotCachedError.
+var ErrNotCached = errors.New("not in the Go module cache")
+
+// NotCachedError is returned when a module, or a file needed to build a
+// version of a module, is missing from the Go module cache.
+type NotCachedError struct {
+	// Module is the module path.
+	Module string
+
+	// Version is the module version or an empty string if none of the
+	// module's versions are cached.
+	Version string
+
+	// Path is the missing file or directory.
+	Path string
+}
+
+// Error implements error.
+func (e *NotCachedError) Error() string {
+	mod := e.Module
+	if e.Version != "" {
+		mod += "@" + e.Version
+	}
+
+	return mod + " is " + ErrNotCached.Error() + ": " + e.Path + " is missing"
+}
+
+// Is allows NotCachedError to match ErrNotCached.
+func (e *NotCachedError) Is(target error) bool {
+	return target == ErrNotCached
+}
@@ -0,0 +1,394 @@
+// Package modcache reads Go module information from the local Go module
+// cache so that versions that have already been downloaded can be
+// listed and installed without network access.
+//
+// The download cache uses the same layout as the [GOPROXY protocol]
+// under $GOMODCACHE/cache/download.
+//
+// [GOPROXY protocol]: https://go.dev/ref/mod#goproxy-protocol
+package modcache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/lmittmann/tint"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/gover"
+)
+
+// SourceName is the source recorded for versions read from the Go
+// module cache.
+const SourceName = "modcache"
+
+// Collector creates a gover.Collector that reads the Go module cache
+// (see Versions) in offline mode (AGI_OFFLINE) and uses the provided
+// Collector otherwise.  The online Collector is never called in offline
+// mode, which also covers sources that don't make HTTP requests through
+// the transport package (e.g. gittag.)
+func Collector(online gover.Collector) gover.Collector {
+	return func(ctx context.Context, cfg *config.Config, pkg string) (*gover.Collection, error) {
+		if cfg.Env().Offline() {
+			col, err := Versions(ctx, cfg, pkg)
+			if err != nil {
+				return nil, err
+			}
+
+			return col.WithSource(SourceName), nil
+		}
+
+		return online(ctx, cfg, pkg)
+	}
+}
+
+var _ gover.Collector = Versions
+
+// Versions retrieves the versions of the Go package's module that are
+// recorded in the Go module cache.  The versions are read from the
+// module's cached version list and from the .info file of each version
+// that has been downloaded.  Newer major versions of the module (with a
+// /vN suffix) are included when they're also cached.
+//
+// If no module containing the package is cached, a NotCachedError is
+// returned.
+func Versions(ctx context.Context, cfg *config.Config, pkg string) (*gover.Collection, error) {
+	root, err := downloadDir(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	mod, col, err := findModule(ctx, cfg, root, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Log().Debug(
+		"Resolved cached module",
+		slog.String("package", pkg),
+		slog.String("module", mod),
+	)
+
+	prefix, pathMajor, ok := module.SplitPathVersion(mod)
+	if !ok || strings.HasPrefix(mod, "gopkg.in/") {
+		return col, nil
+	}
+
+	major := 1
+	if pathMajor != "" {
+		major, _ = strconv.Atoi(strings.TrimPrefix(pathMajor, "/v"))
+	}
+
+	for major++; ; major++ {
+		next, err := collection(cfg, root, fmt.Sprintf("%s/v%d", prefix, major))
+		if errors.Is(err, ErrNotCached) {
+			return col, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		col = col.Union(next)
+	}
+}
+
+// Verify checks that the go command can build the version of the module
+// without network access: the module's zip file and the go.mod file of
+// each of its requirements must be in the Go module cache.  The zip
+// files of the requirements aren't checked, since the go command only
+// downloads them for the modules that provide imported packages; a
+// missing one fails go install itself (which runs with GOPROXY=off.)
+//
+// A NotCachedError is returned for each missing file.
+func Verify(ctx context.Context, cfg *config.Config, mod string, ver string) error {
+	root, err := downloadDir(cfg)
+	if err != nil {
+		return err
+	}
+
+	if mod == "" {
+		return fmt.Errorf("%w: the module providing version %s is unknown", ErrNotCached, ver)
+	}
+
+	if _, err := cached(root, mod, ver, ".zip"); err != nil {
+		return err
+	}
+
+	modPath, err := cached(root, mod, ver, ".mod")
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		return err
+	}
+
+	f, err := modfile.ParseLax(modPath, data, nil)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, req := range f.Require {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if _, err := cached(root, req.Mod.Path, req.Mod.Version, ".mod"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Zip returns the contents of the module zip that the go command stored
+// in the Go module cache for the version of the module.  A
+// NotCachedError is returned if the zip isn't cached.
+func Zip(cfg *config.Config, mod string, ver string) ([]byte, error) {
+	root, err := downloadDir(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	zipPath, err := cached(root, mod, ver, ".zip")
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(zipPath)
+}
+
+// ZipHash returns the h1: hash of the module zip that the go command
+// recorded in the Go module cache (in the .ziphash file) after checking
+// the zip against go.sum or the checksum database.  A NotCachedError
+// is returned if the hash isn't cached.
+func ZipHash(cfg *config.Config, mod string, ver string) (string, error) {
+	root, err := downloadDir(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	hashPath, err := cached(root, mod, ver, ".ziphash")
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(hashPath)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+func downloadDir(cfg *config.Config) (string, error) {
+	modCache := cfg.Env().GoModCache()
+	if modCache == "" {
+		return "", ErrNoModCache
+	}
+
+	return filepath.Join(modCache, "cache", "download"), nil
+}
+
+// findModule walks up the package's path until it finds the longest
+// prefix that's cached as a module and returns that module path along
+// with its versions.
+func findModule(ctx context.Context, cfg *config.Config, root string, pkg string) (string, *gover.Collection, error) {
+	var lastErr error = &NotCachedError{Module: pkg, Path: root}
+
+	for mod := pkg; mod != "." && mod != "/"; mod = path.Dir(mod) {
+		if err := ctx.Err(); err != nil {
+			return "", nil, err
+		}
+
+		col, err := collection(cfg, root, mod)
+		if errors.Is(err, ErrNotCached) {
+			lastErr = err
+
+			continue
+		}
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		return mod, col, nil
+	}
+
+	return "", nil, fmt.Errorf("no cached module contains package %s: %w", pkg, lastErr)
+}
+
+// collection reads the module's cached versions along with the
+// retractions declared by the cached go.mod file of its latest version.
+func collection(cfg *config.Config, root string, mod string) (*gover.Collection, error) {
+	dir, err := versionsDir(root, mod)
+	if err != nil {
+		return nil, err
+	}
+
+	strs, err := cachedVersions(dir)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && len(strs) == 0) {
+		return nil, &NotCachedError{Module: mod, Path: dir}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var vers semver.Collection
+
+	for _, str := range strs {
+		ver, err := gover.NewVersion(str)
+		if err != nil {
+			cfg.Log().Warn(
+				"Skipping invalid Go version",
+				slog.String("module", mod),
+				slog.String("version", str),
+				tint.Err(err),
+			)
+
+			continue
+		}
+
+		vers = append(vers, ver)
+	}
+
+	col := gover.NewCollection(vers...).WithModule(mod)
+	if col.Len() == 0 {
+		return col, nil
+	}
+
+	latest, err := col.LatestStable()
+	if err != nil {
+		latest = col.All()[col.Len()-1]
+	}
+
+	modPath, err := cached(root, mod, latest.Original(), ".mod")
+	if err != nil {
+		return col, nil
+	}
+
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := gover.ParseRetractions(modPath, data)
+	if err != nil {
+		cfg.Log().Warn(
+			"Skipping retractions",
+			slog.String("module", mod),
+			slog.String("version", latest.Original()),
+			tint.Err(err),
+		)
+
+		return col, nil
+	}
+
+	return col.WithRetractions(rs...), nil
+}
+
+// cachedVersions returns the unique versions listed in the cached list
+// file and named by the cached .info files.
+func cachedVersions(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		seen = map[string]bool{}
+		strs []string
+	)
+
+	add := func(str string) {
+		if str != "" && !seen[str] {
+			seen[str] = true
+			strs = append(strs, str)
+		}
+	}
+
+	for _, entry := range entries {
+		switch {
+		case entry.Name() == "list":
+			data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+
+			scanner := bufio.NewScanner(bytes.NewReader(data))
+			for scanner.Scan() {
+				add(strings.TrimSpace(scanner.Text()))
+			}
+
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+		case strings.HasSuffix(entry.Name(), ".info"):
+			data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+
+			var info struct{ Version string }
+
+			if err := json.Unmarshal(data, &info); err != nil {
+				return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+			}
+
+			add(info.Version)
+		}
+	}
+
+	return strs, nil
+}
+
+// cached returns the path of the module version's file with the provided
+// extension or a NotCachedError if the file doesn't exist.
+func cached(root string, mod string, ver string, ext string) (string, error) {
+	dir, err := versionsDir(root, mod)
+	if err != nil {
+		return "", err
+	}
+
+	escVer, err := module.EscapeVersion(ver)
+	if err != nil {
+		return "", err
+	}
+
+	file := filepath.Join(dir, escVer+ext)
+	if _, err := os.Stat(file); errors.Is(err, fs.ErrNotExist) {
+		return "", &NotCachedError{Module: mod, Version: ver, Path: file}
+	} else if err != nil {
+		return "", err
+	}
+
+	return file, nil
+}
+
+func versionsDir(root string, mod string) (string, error) {
+	escMod, err := module.EscapePath(mod)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(root, filepath.FromSlash(escMod), "@v"), nil
+}
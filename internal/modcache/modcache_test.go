@@ -0,0 +1,234 @@
+package modcache_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/gover/govertest"
+	"github.com/selesy/asdf-go-install/internal/modcache"
+)
+
+var errCollect = errors.New("collector should not be called")
+
+func TestVersions(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		pkg       string
+		exp       string
+		expModule map[string]string
+		expErr    error
+	}{
+		"pass with list, info files and newer major versions": {
+			pkg: "example.com/tool/cmd/tool",
+			exp: "v1.0.0 v1.1.0 v1.1.1-rc.1 v2.0.0",
+			expModule: map[string]string{
+				"v1.1.0": "example.com/tool",
+				"v2.0.0": "example.com/tool/v2",
+			},
+		},
+		"pass with major version suffix": {
+			pkg: "example.com/tool/v2/cmd/tool",
+			exp: "v2.0.0",
+			expModule: map[string]string{
+				"v2.0.0": "example.com/tool/v2",
+			},
+		},
+		"pass with escaped module path": {
+			pkg: "github.com/Example/Tool",
+			exp: "v0.1.0",
+			expModule: map[string]string{
+				"v0.1.0": "github.com/Example/Tool",
+			},
+		},
+		"fail when module isn't cached": {
+			pkg:    "example.com/other/cmd/other",
+			expErr: modcache.ErrNotCached,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := newConfig(t)
+
+			col, err := modcache.Versions(context.Background(), cfg, test.pkg)
+			require.ErrorIs(t, err, test.expErr)
+
+			if err != nil {
+				assert.Nil(t, col)
+
+				var notCached *modcache.NotCachedError
+
+				require.ErrorAs(t, err, &notCached)
+				assert.Equal(t, "example.com", notCached.Module)
+
+				return
+			}
+
+			assert.Equal(t, test.exp, col.String())
+
+			for ver, mod := range test.expModule {
+				assert.Equal(t, mod, col.Module(govertest.NewVersion(t, ver)))
+			}
+		})
+	}
+}
+
+func TestVersions_Retractions(t *testing.T) {
+	t.Parallel()
+
+	col, err := modcache.Versions(context.Background(), newConfig(t), "example.com/tool")
+	require.NoError(t, err)
+	assert.True(t, col.IsRetracted(govertest.NewVersion(t, "v1.0.0")))
+	assert.False(t, col.IsRetracted(govertest.NewVersion(t, "v1.1.0")))
+}
+
+func TestVersions_NoModCache(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"GOMODCACHE=", "GOPATH=", "HOME="}, []string{})
+
+	col, err := modcache.Versions(context.Background(), cfg, "example.com/tool")
+	require.ErrorIs(t, err, modcache.ErrNoModCache)
+	assert.Nil(t, col)
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		mod     string
+		ver     string
+		expErr  error
+		expPath string
+	}{
+		"pass when zip and requirements are cached": {
+			mod: "example.com/tool",
+			ver: "v1.1.0",
+		},
+		"fail when requirement isn't cached": {
+			mod:     "example.com/tool",
+			ver:     "v1.0.0",
+			expErr:  modcache.ErrNotCached,
+			expPath: "example.com/missing/@v/v0.1.0.mod",
+		},
+		"pass when requirement's zip isn't cached": {
+			mod: "example.com/app",
+			ver: "v1.0.0",
+		},
+		"fail when zip isn't cached": {
+			mod:     "example.com/tool/v2",
+			ver:     "v2.0.0",
+			expErr:  modcache.ErrNotCached,
+			expPath: "example.com/tool/v2/@v/v2.0.0.zip",
+		},
+		"fail when module is unknown": {
+			ver:    "v1.1.0",
+			expErr: modcache.ErrNotCached,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := modcache.Verify(context.Background(), newConfig(t), test.mod, test.ver)
+			require.ErrorIs(t, err, test.expErr)
+
+			if test.expPath != "" {
+				var notCached *modcache.NotCachedError
+
+				require.ErrorAs(t, err, &notCached)
+				assert.Equal(t, filepath.FromSlash(test.expPath), relPath(t, notCached.Path))
+			}
+		})
+	}
+}
+
+func TestZip(t *testing.T) {
+	t.Parallel()
+
+	exp, err := os.ReadFile(filepath.Join("testdata", "modcache", "cache", "download", "example.com", "tool", "@v", "v1.1.0.zip"))
+	require.NoError(t, err)
+
+	data, err := modcache.Zip(newConfig(t), "example.com/tool", "v1.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, exp, data)
+
+	data, err = modcache.Zip(newConfig(t), "example.com/tool/v2", "v2.0.0")
+	require.ErrorIs(t, err, modcache.ErrNotCached)
+	assert.Nil(t, data)
+}
+
+func TestZipHash(t *testing.T) {
+	t.Parallel()
+
+	hash, err := modcache.ZipHash(newConfig(t), "example.com/tool", "v1.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", hash)
+
+	hash, err = modcache.ZipHash(newConfig(t), "example.com/tool", "v1.0.0")
+	require.ErrorIs(t, err, modcache.ErrNotCached)
+	assert.Empty(t, hash)
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	online := func(context.Context, *config.Config, string) (*gover.Collection, error) {
+		return nil, errCollect
+	}
+
+	t.Run("offline", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := newConfig(t, "AGI_OFFLINE=true")
+
+		col, err := modcache.Collector(online)(context.Background(), cfg, "example.com/tool/v2")
+		require.NoError(t, err)
+		assert.Equal(t, "v2.0.0", col.String())
+		assert.Equal(t, modcache.SourceName, col.Source(govertest.NewVersion(t, "v2.0.0")))
+	})
+
+	t.Run("online", func(t *testing.T) {
+		t.Parallel()
+
+		col, err := modcache.Collector(online)(context.Background(), newConfig(t), "example.com/tool/v2")
+		require.ErrorIs(t, err, errCollect)
+		assert.Nil(t, col)
+	})
+}
+
+func newConfig(t *testing.T, environ ...string) *config.Config {
+	t.Helper()
+
+	modCache, err := filepath.Abs(filepath.Join("testdata", "modcache"))
+	require.NoError(t, err)
+
+	cfg, _, _ := configtest.NewConfig(t, append(environ, "GOMODCACHE="+modCache), []string{})
+
+	return cfg
+}
+
+func relPath(t *testing.T, path string) string {
+	t.Helper()
+
+	root, err := filepath.Abs(filepath.Join("testdata", "modcache", "cache", "download"))
+	require.NoError(t, err)
+
+	rel, err := filepath.Rel(root, path)
+	require.NoError(t, err)
+
+	return rel
+}
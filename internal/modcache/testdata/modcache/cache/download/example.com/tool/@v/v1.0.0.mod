@@ -0,0 +1,5 @@
+module example.com/tool
+
+go 1.21
+
+require example.com/missing v0.1.0
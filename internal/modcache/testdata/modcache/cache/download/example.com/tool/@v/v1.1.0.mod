@@ -0,0 +1,8 @@
+module example.com/tool
+
+go 1.21
+
+require example.com/lib v1.0.0
+
+// Published with a missing dependency.
+retract v1.0.0
@@ -0,0 +1,3 @@
+module github.com/Example/Tool
+
+go 1.21
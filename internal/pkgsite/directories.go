@@ -0,0 +1,148 @@
+package pkgsite
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+)
+
+const (
+	headerTitleSelector       = "html body main header div.go-Main-headerTitle"
+	directoriesSelector       = "html body main article section.UnitDirectories"
+	directoryRowSelector      = "table tr[data-id]"
+	directoryLinkSelector     = "div.UnitDirectories-pathCell a"
+	directorySynopsisSelector = "td.UnitDirectories-desktopSynopsis"
+	chipSelector              = "span.go-Chip"
+
+	commandChip = "command"
+)
+
+// Command describes a command (package main) listed by pkg.go.dev.
+type Command struct {
+	// Path is the command's import path.
+	Path string
+
+	// Synopsis is the first sentence of the command's documentation.
+	Synopsis string
+
+	// Version is the version of the command's module that was listed.
+	Version string
+}
+
+// Commands scrapes the commands provided by the provided version of the
+// module from the module's "Directories" section on the pkg.go.dev
+// web-site (or the pkgsite mirror configured by AGI_PKGSITE_URL.)  An
+// empty version selects the module's latest version.  The module's root
+// package is included if it's a command.
+//
+// Requests are retried and errors are reported as described for
+// Repository, except that ErrLayoutChanged is returned if the page
+// doesn't contain the module's header details.  See mainpkg.Commands
+// for an alternative that inspects the module zip instead.
+func Commands(ctx context.Context, cfg *config.Config, mod string, ver string) ([]*Command, error) {
+	return commands(ctx, cfg, defaultOptions, mod, ver)
+}
+
+func commands(ctx context.Context, cfg *config.Config, opts options, mod string, ver string) ([]*Command, error) {
+	target := mod
+	if ver != "" {
+		target += "@" + ver
+	}
+
+	u := cfg.Env().PkgsiteURL().JoinPath(target)
+
+	cfg.Log().Debug(
+		"Scraping target",
+		slog.String("url", u.String()),
+		slog.String("goal", "commands"),
+	)
+
+	var (
+		cmds     []*Command
+		root     *Command
+		found    bool
+		synopsis string
+	)
+
+	err := scrape(ctx, cfg, opts, u, func(col *colly.Collector) {
+		col.OnHTML(synopsisSelector, func(h *colly.HTMLElement) {
+			synopsis = strings.TrimSpace(h.Attr("content"))
+		})
+
+		col.OnHTML(headerTitleSelector, func(h *colly.HTMLElement) {
+			if isCommand(h, chipSelector) {
+				root = &Command{Path: mod}
+			}
+		})
+
+		col.OnHTML(headerSelector, func(h *colly.HTMLElement) {
+			found = true
+
+			ver = strings.TrimSpace(h.ChildText(headerVersionSelector))
+			ver = strings.TrimSpace(strings.TrimPrefix(ver, "Version:"))
+		})
+
+		col.OnHTML(directoriesSelector, func(h *colly.HTMLElement) {
+			h.ForEach(directoryRowSelector, func(_ int, h *colly.HTMLElement) {
+				if !isCommand(h, chipSelector) {
+					return
+				}
+
+				cmds = append(cmds, &Command{
+					Path:     directoryPath(h.ChildAttr(directoryLinkSelector, "href")),
+					Synopsis: strings.Join(strings.Fields(h.ChildText(directorySynopsisSelector)), " "),
+				})
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: no module details in %s", ErrLayoutChanged, u)
+	}
+
+	if root != nil {
+		root.Synopsis = synopsis
+		cmds = append([]*Command{root}, cmds...)
+	}
+
+	for _, cmd := range cmds {
+		cmd.Version = ver
+	}
+
+	return cmds, nil
+}
+
+// isCommand reports whether the element contains pkg.go.dev's "command"
+// chip at the selector.
+func isCommand(h *colly.HTMLElement, selector string) bool {
+	var cmd bool
+
+	h.ForEach(selector, func(_ int, h *colly.HTMLElement) {
+		cmd = cmd || strings.TrimSpace(h.Text) == commandChip
+	})
+
+	return cmd
+}
+
+// directoryPath converts a link from the "Directories" section (which
+// includes the version when a specific version was requested) into an
+// import path.
+func directoryPath(href string) string {
+	p := strings.TrimPrefix(href, "/")
+
+	if before, after, ok := strings.Cut(p, "@"); ok {
+		_, subpath, _ := strings.Cut(after, "/")
+		p = path.Join(before, subpath)
+	}
+
+	return p
+}
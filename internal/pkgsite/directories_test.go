@@ -0,0 +1,88 @@
+package pkgsite_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/pkgsite"
+	"github.com/selesy/asdf-go-install/internal/transport"
+)
+
+func TestCommands(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(fixtures(t))
+	t.Cleanup(srv.Close)
+
+	tests := map[string]struct {
+		mod    string
+		ver    string
+		exp    []*pkgsite.Command
+		expErr error
+	}{
+		"pass with latest version": {
+			mod: "golang.org/x/tools",
+			exp: []*pkgsite.Command{
+				{
+					Path:     "golang.org/x/tools/cmd/bisect",
+					Synopsis: "Bisect finds changes responsible for causing a failure.",
+					Version:  "v0.28.0",
+				},
+				{
+					Path:     "golang.org/x/tools/cmd/stringer",
+					Synopsis: "Stringer is a tool to automate the creation of methods that satisfy the fmt.Stringer interface.",
+					Version:  "v0.28.0",
+				},
+			},
+		},
+		"pass with explicit version and root command": {
+			mod: "example.com/app",
+			ver: "v1.2.0",
+			exp: []*pkgsite.Command{
+				{
+					Path:     "example.com/app",
+					Synopsis: "App serves things over HTTP.",
+					Version:  "v1.2.0",
+				},
+				{
+					Path:     "example.com/app/cmd/appctl",
+					Synopsis: "Appctl manages a running app.",
+					Version:  "v1.2.0",
+				},
+			},
+		},
+		"fail when module isn't found": {
+			mod:    "example.com/missing",
+			expErr: pkgsite.ErrPackageNotFound,
+		},
+		"fail when layout has changed": {
+			mod:    "example.com/changed",
+			expErr: pkgsite.ErrLayoutChanged,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cmds, err := pkgsite.Commands(context.Background(), newConfig(t, srv.URL), test.mod, test.ver)
+			require.ErrorIs(t, err, test.expErr)
+			assert.Equal(t, test.exp, cmds)
+		})
+	}
+}
+
+func TestCommands_Offline(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"AGI_OFFLINE=true"}, []string{})
+
+	cmds, err := pkgsite.Commands(context.Background(), cfg, "golang.org/x/tools", "")
+	require.ErrorIs(t, err, transport.ErrOffline)
+	assert.Nil(t, cmds)
+}
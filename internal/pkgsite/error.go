@@ -0,0 +1,20 @@
+package pkgsite
+
+import "errors"
+
+// ErrLayoutChanged is returned when the elements that are scraped can't
+// be found in a page, which likely means that the layout of pkg.go.dev
+// has changed.
+var ErrLayoutChanged = errors.New("pkg.go.dev page layout has changed")
+
+// ErrPackageNotFound is returned when pkg.go.dev responds with a 404
+// (Not Found) status.
+var ErrPackageNotFound = errors.New("package not found on pkg.go.dev")
+
+// ErrRateLimited is returned when pkg.go.dev is still responding with a
+// 429 (Too Many Requests) status after the request has been retried.
+var ErrRateLimited = errors.New("rate limited by pkg.go.dev")
+
+// ErrUnexpectedStatus is returned when pkg.go.dev responds with a status
+// other than 200 (OK), 404 (Not Found) or 429 (Too Many Requests.)
+var ErrUnexpectedStatus = errors.New("unexpected response from pkg.go.dev")
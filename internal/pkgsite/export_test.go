@@ -0,0 +1,32 @@
+package pkgsite
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/gover"
+)
+
+// RetryAttempts is the number of requests made before giving up.
+const RetryAttempts = retryAttempts
+
+// RepositoryWithBackoff allows tests to shorten the delay between
+// retries.
+func RepositoryWithBackoff(ctx context.Context, cfg *config.Config, backoff time.Duration, pkg string) (*url.URL, error) {
+	return repository(ctx, cfg, testOptions(backoff), pkg)
+}
+
+// VersionsWithBackoff allows tests to shorten the delay between retries.
+func VersionsWithBackoff(ctx context.Context, cfg *config.Config, backoff time.Duration, pkg string) (*gover.Collection, error) {
+	return versions(ctx, cfg, testOptions(backoff), pkg)
+}
+
+func testOptions(backoff time.Duration) options {
+	opts := defaultOptions
+	opts.retry.backoff = backoff
+	opts.retry.maxBackoff = 10 * backoff
+
+	return opts
+}
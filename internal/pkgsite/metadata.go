@@ -0,0 +1,184 @@
+package pkgsite
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gocolly/colly/v2"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+)
+
+const (
+	synopsisSelector      = "html head meta[name=description]"
+	headerSelector        = "html body main header div.go-Main-headerDetails"
+	headerVersionSelector = "span[data-test-id=UnitHeader-version] a"
+	headerLicenseSelector = "span[data-test-id=UnitHeader-licenses] a"
+	headerImportsSelector = "span[data-test-id=UnitHeader-importedby] a"
+	deprecatedSelector    = "html body main header div.UnitHeader-banner--deprecated span.UnitHeader-bannerContent"
+	retractedSelector     = "html body main header div.UnitHeader-banner--retracted span.UnitHeader-bannerContent"
+	majorVersionSelector  = "html body main header div.UnitHeader-banner--majorVersion a"
+)
+
+// Metadata is the information that pkg.go.dev shows about a Go package
+// in addition to its versions and repository.
+type Metadata struct {
+	// Package is the package's import path.
+	Package string `json:"package"`
+
+	// Version is the version of the package's module that the metadata
+	// describes (normally the latest version.)
+	Version string `json:"version"`
+
+	// Synopsis is the first sentence of the package's documentation.
+	Synopsis string `json:"synopsis,omitempty"`
+
+	// Licenses are the names of the licenses detected in the module.
+	Licenses []string `json:"licenses,omitempty"`
+
+	// ImportedBy is the number of packages that import the package.
+	ImportedBy int `json:"importedBy"`
+
+	// Deprecated indicates that the module's author has deprecated the
+	// module and DeprecationMessage contains their explanation.
+	Deprecated         bool   `json:"deprecated,omitempty"`
+	DeprecationMessage string `json:"deprecationMessage,omitempty"`
+
+	// Retracted indicates that the module's author has retracted the
+	// version and RetractionRationale contains their explanation.
+	Retracted           bool   `json:"retracted,omitempty"`
+	RetractionRationale string `json:"retractionRationale,omitempty"`
+
+	// LatestMajorVersion is the path of the module's newest major
+	// version (e.g. example.com/tool/v3) or an empty string if the
+	// package's module is the newest major version.
+	LatestMajorVersion string `json:"latestMajorVersion,omitempty"`
+}
+
+// WriteOverview writes the Metadata as the aligned, human-readable text
+// shown by the help.overview script and the info extension command.
+func (m *Metadata) WriteOverview(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+
+	line := func(label string, value string) {
+		if value != "" {
+			fmt.Fprintf(tw, "%s:\t%s\n", label, value)
+		}
+	}
+
+	line("Package", m.Package)
+	line("Version", m.Version)
+	line("Synopsis", m.Synopsis)
+	line("License", strings.Join(m.Licenses, ", "))
+	line("Imported by", strconv.Itoa(m.ImportedBy))
+
+	if m.Deprecated {
+		line("Deprecated", cmp.Or(m.DeprecationMessage, "yes"))
+	}
+
+	if m.Retracted {
+		line("Retracted", cmp.Or(m.RetractionRationale, "yes"))
+	}
+
+	line("Latest major version", m.LatestMajorVersion)
+
+	return tw.Flush()
+}
+
+// PackageMetadata scrapes the Metadata of the Go package from its page
+// on the pkg.go.dev web-site (or the pkgsite mirror configured by
+// AGI_PKGSITE_URL.)
+//
+// Requests are retried and errors are reported as described for
+// Repository, except that ErrLayoutChanged is returned if the page
+// doesn't contain the package's header details.
+func PackageMetadata(ctx context.Context, cfg *config.Config, pkg string) (*Metadata, error) {
+	return packageMetadata(ctx, cfg, defaultOptions, pkg)
+}
+
+func packageMetadata(ctx context.Context, cfg *config.Config, opts options, pkg string) (*Metadata, error) {
+	u := cfg.Env().PkgsiteURL().JoinPath(pkg)
+
+	cfg.Log().Debug(
+		"Scraping target",
+		slog.String("url", u.String()),
+		slog.String("goal", "metadata"),
+	)
+
+	var (
+		md    = &Metadata{Package: pkg}
+		found bool
+	)
+
+	err := scrape(ctx, cfg, opts, u, func(col *colly.Collector) {
+		col.OnHTML(synopsisSelector, func(h *colly.HTMLElement) {
+			md.Synopsis = strings.TrimSpace(h.Attr("content"))
+		})
+
+		col.OnHTML(headerSelector, func(h *colly.HTMLElement) {
+			found = true
+
+			md.Version = strings.TrimSpace(h.ChildText(headerVersionSelector))
+			md.Version = strings.TrimSpace(strings.TrimPrefix(md.Version, "Version:"))
+
+			h.ForEach(headerLicenseSelector, func(_ int, h *colly.HTMLElement) {
+				md.Licenses = append(md.Licenses, strings.TrimSpace(h.Text))
+			})
+
+			md.ImportedBy = parseCount(h.ChildText(headerImportsSelector))
+		})
+
+		col.OnHTML(deprecatedSelector, func(h *colly.HTMLElement) {
+			md.Deprecated = true
+			md.DeprecationMessage = bannerText(h.Text, "Deprecated:")
+		})
+
+		col.OnHTML(retractedSelector, func(h *colly.HTMLElement) {
+			md.Retracted = true
+			md.RetractionRationale = bannerText(h.Text, "Retracted:")
+		})
+
+		col.OnHTML(majorVersionSelector, func(h *colly.HTMLElement) {
+			md.LatestMajorVersion = strings.Trim(h.Attr("href"), "/")
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: no package details in %s", ErrLayoutChanged, u)
+	}
+
+	return md, nil
+}
+
+// parseCount extracts the number from text like "Imported by: 1,234".
+func parseCount(text string) int {
+	var digits strings.Builder
+
+	for _, r := range text {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+
+	n, _ := strconv.Atoi(digits.String())
+
+	return n
+}
+
+// bannerText removes the banner's label and collapses the whitespace
+// left by the page's markup.
+func bannerText(text string, label string) string {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, label)
+
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,91 @@
+package pkgsite_test
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gotest.tools/v3/golden"
+
+	"github.com/selesy/asdf-go-install/internal/pkgsite"
+)
+
+func TestPackageMetadata(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(fixtures(t))
+	t.Cleanup(srv.Close)
+
+	tests := map[string]struct {
+		pkg    string
+		exp    *pkgsite.Metadata
+		expErr error
+	}{
+		"pass with latest version": {
+			pkg: pkg,
+			exp: &pkgsite.Metadata{
+				Package:  pkg,
+				Version:  "v1.1.3",
+				Synopsis: "Govulncheck reports known vulnerabilities that affect Go code.",
+				Licenses: []string{"BSD-3-Clause"},
+			},
+		},
+		"pass with banners": {
+			pkg: "example.com/tool/cmd/tool",
+			exp: &pkgsite.Metadata{
+				Package:             "example.com/tool/cmd/tool",
+				Version:             "v1.0.1",
+				Synopsis:            "Tool does things to files.",
+				Licenses:            []string{"Apache-2.0", "MIT"},
+				ImportedBy:          1234,
+				Deprecated:          true,
+				DeprecationMessage:  "use example.com/tool/v3 instead.",
+				Retracted:           true,
+				RetractionRationale: "Published with a broken build.",
+				LatestMajorVersion:  "example.com/tool/v3",
+			},
+		},
+		"fail when package isn't found": {
+			pkg:    "example.com/missing",
+			expErr: pkgsite.ErrPackageNotFound,
+		},
+		"fail when layout has changed": {
+			pkg:    "example.com/changed",
+			expErr: pkgsite.ErrLayoutChanged,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			md, err := pkgsite.PackageMetadata(context.Background(), newConfig(t, srv.URL), test.pkg)
+			require.ErrorIs(t, err, test.expErr)
+			assert.Equal(t, test.exp, md)
+		})
+	}
+}
+
+func TestMetadata_WriteOverview(t *testing.T) {
+	t.Parallel()
+
+	md := &pkgsite.Metadata{
+		Package:            "example.com/tool/cmd/tool",
+		Version:            "v1.0.1",
+		Synopsis:           "Tool does things to files.",
+		Licenses:           []string{"Apache-2.0", "MIT"},
+		ImportedBy:         1234,
+		Deprecated:         true,
+		DeprecationMessage: "use example.com/tool/v3 instead.",
+		Retracted:          true,
+		LatestMajorVersion: "example.com/tool/v3",
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, md.WriteOverview(&buf))
+	golden.Assert(t, buf.String(), "overview.txt")
+}
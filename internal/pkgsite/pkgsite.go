@@ -3,9 +3,13 @@
 package pkgsite
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/gocolly/colly/v2"
@@ -13,28 +17,49 @@ import (
 
 	"github.com/selesy/asdf-go-install/internal/config"
 	"github.com/selesy/asdf-go-install/internal/gover"
+	"github.com/selesy/asdf-go-install/internal/transport"
 )
 
 const (
-	packageSiteBaseURL         = "https://pkg.go.dev"
 	packageSiteTabKey          = "tab"
 	packageSiteVersionTabValue = "versions"
+
+	repositorySelector  = "html body main aside div.UnitMeta div.UnitMeta-repo a"
+	versionsSelector    = "html body main article div.Versions"
+	versionTagsSelector = "div.Versions-list div.Version-tag a"
+
+	retryAttempts   = 4
+	retryBackoff    = time.Second
+	retryMaxBackoff = 30 * time.Second
 )
 
-// Repository scrapes the URL of the Go package's Git repository from
-// the pkg.go.dev web-site.
-func Repository(cfg *config.Config, pkg string) (*url.URL, error) {
-	var (
-		u   *url.URL
-		err error
-	)
+// options are the settings used to scrape pkg.go.dev.
+type options struct {
+	retry retryTransport
+}
 
-	u, err = url.Parse(packageSiteBaseURL)
-	if err != nil {
-		return nil, err
-	}
+var defaultOptions = options{
+	retry: retryTransport{
+		attempts:   retryAttempts,
+		backoff:    retryBackoff,
+		maxBackoff: retryMaxBackoff,
+	},
+}
 
-	u.Path = "/" + pkg
+// Repository scrapes the URL of the Go package's Git repository from
+// the pkg.go.dev web-site (or the pkgsite mirror configured by
+// AGI_PKGSITE_URL.)
+//
+// Requests that are rate limited or fail with a temporary server error
+// are retried.  ErrPackageNotFound, ErrRateLimited or
+// ErrUnexpectedStatus is returned if the page can't be retrieved and
+// ErrLayoutChanged is returned if the page doesn't link to a repository.
+func Repository(ctx context.Context, cfg *config.Config, pkg string) (*url.URL, error) {
+	return repository(ctx, cfg, defaultOptions, pkg)
+}
+
+func repository(ctx context.Context, cfg *config.Config, opts options, pkg string) (*url.URL, error) {
+	u := cfg.Env().PkgsiteURL().JoinPath(pkg)
 
 	cfg.Log().Debug(
 		"Scraping target repository URL",
@@ -42,40 +67,46 @@ func Repository(cfg *config.Config, pkg string) (*url.URL, error) {
 		slog.String("goal", "repository"),
 	)
 
-	col := colly.NewCollector()
-
-	var repo *url.URL
-
-	col.OnError(func(r *colly.Response, e error) {
-		cfg.Log().Error("Colly error", tint.Err(err))
-		err = e
-	})
+	var (
+		repo     *url.URL
+		found    bool
+		parseErr error
+	)
 
-	col.OnHTML("html body main aside div.UnitMeta div.UnitMeta-repo a", func(h *colly.HTMLElement) {
-		repo, err = url.Parse(h.Attr("href"))
+	err := scrape(ctx, cfg, opts, u, func(col *colly.Collector) {
+		col.OnHTML(repositorySelector, func(h *colly.HTMLElement) {
+			found = true
+			repo, parseErr = url.Parse(h.Attr("href"))
+		})
 	})
-
-	if err := col.Visit(u.String()); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
-	col.Wait()
+	if !found {
+		return nil, fmt.Errorf("%w: no repository link in %s", ErrLayoutChanged, u)
+	}
 
-	return repo, err
+	return repo, parseErr
 }
 
 var _ gover.Collector = Versions
 
 // Versions scrapes the available versions of the Go package from the
-// pkg.go.dev web-site.
-func Versions(cfg *config.Config, pkg string) (*gover.Collection, error) {
-	u, err := url.Parse(packageSiteBaseURL)
-	if err != nil {
-		return nil, err
-	}
+// pkg.go.dev web-site (or the pkgsite mirror configured by
+// AGI_PKGSITE_URL.)
+//
+// Requests are retried and errors are reported as described for
+// Repository, except that ErrLayoutChanged is returned if the page
+// doesn't contain a list of versions with at least one valid version
+// tag.
+func Versions(ctx context.Context, cfg *config.Config, pkg string) (*gover.Collection, error) {
+	return versions(ctx, cfg, defaultOptions, pkg)
+}
 
-	u.Path = "/" + pkg
-	u.RawQuery = "tab=versions"
+func versions(ctx context.Context, cfg *config.Config, opts options, pkg string) (*gover.Collection, error) {
+	u := cfg.Env().PkgsiteURL().JoinPath(pkg)
+	u.RawQuery = url.Values{packageSiteTabKey: {packageSiteVersionTabValue}}.Encode()
 
 	cfg.Log().Debug(
 		"Scraping target",
@@ -83,36 +114,108 @@ func Versions(cfg *config.Config, pkg string) (*gover.Collection, error) {
 		slog.String("goal", "versions"),
 	)
 
-	col := colly.NewCollector()
+	var (
+		vers  semver.Collection
+		found bool
+	)
 
-	col.OnError(func(r *colly.Response, err error) {
-		cfg.Log().Error("Colly error", tint.Err(err))
-		// TODO: this err should stop the plugin execution
+	err := scrape(ctx, cfg, opts, u, func(col *colly.Collector) {
+		col.OnHTML(versionsSelector, func(h *colly.HTMLElement) {
+			h.ForEach(versionTagsSelector, func(_ int, h *colly.HTMLElement) {
+				ver, err := gover.NewVersion(h.Text)
+				if err != nil {
+					cfg.Log().Warn(
+						"Skipping invalid Go version",
+						slog.String("package", pkg),
+						slog.String("version", strings.TrimPrefix(h.Text, "v")),
+						tint.Err(err),
+					)
+
+					return
+				}
+
+				vers, found = append(vers, ver), true
+			})
+		})
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	var vers semver.Collection
+	if !found {
+		return nil, fmt.Errorf("%w: no version tags in %s", ErrLayoutChanged, u)
+	}
 
-	col.OnHTML("html body main article div.Versions div.Versions-list div.Version-tag a", func(h *colly.HTMLElement) {
-		ver, err := gover.NewVersion(h.Text)
-		if err != nil {
-			cfg.Log().Warn(
-				"Skipping invalid Go version",
-				slog.String("package", pkg),
-				slog.String("version", strings.TrimPrefix(h.Text, "v")),
-				tint.Err(err),
-			)
+	return gover.NewCollection(vers...), nil
+}
 
-			return
-		}
+// scrape visits the page after the caller has registered its callbacks
+// with the collector.  Failed requests are reported using the package's
+// errors (see statusError.)
+func scrape(ctx context.Context, cfg *config.Config, opts options, u *url.URL, register func(*colly.Collector)) error {
+	col := newCollector(ctx, cfg, opts)
 
-		vers = append(vers, ver)
+	var err error
+
+	col.OnError(func(r *colly.Response, e error) {
+		cfg.Log().Error("Colly error", tint.Err(e))
+		err = statusError(u, r, e)
 	})
 
-	if err := col.Visit(u.String()); err != nil {
-		return nil, err
+	register(col)
+
+	// OnError has already recorded a more specific error for failed
+	// requests.
+	if e := col.Visit(u.String()); e != nil && err == nil {
+		err = e
 	}
 
 	col.Wait()
 
-	return gover.NewCollection(vers...), nil
+	if err != nil {
+		return contextError(ctx, err)
+	}
+
+	return nil
+}
+
+// newCollector creates a colly.Collector whose requests are canceled
+// along with the provided context, time out after the configured
+// request timeout and are retried as configured by the options.  The
+// requests are made using transport.New.
+func newCollector(ctx context.Context, cfg *config.Config, opts options) *colly.Collector {
+	retry := opts.retry
+	retry.log = cfg.Log()
+	retry.next = transport.New(ctx, cfg)
+
+	col := colly.NewCollector()
+	col.SetRequestTimeout(cfg.Env().RequestTimeout())
+	col.WithTransport(transport.WithContext(ctx, &retry))
+
+	return col
+}
+
+// statusError converts the error reported by colly for an unsuccessful
+// response into one of the package's errors.
+func statusError(u *url.URL, r *colly.Response, err error) error {
+	switch {
+	case r == nil || r.StatusCode == 0:
+		return err
+	case r.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrPackageNotFound, u)
+	case r.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %s", ErrRateLimited, u)
+	default:
+		return fmt.Errorf("%w: %s: %s", ErrUnexpectedStatus, u, http.StatusText(r.StatusCode))
+	}
+}
+
+// contextError prefers the context's error, if any, since colly doesn't
+// always wrap the error returned by the HTTP client.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	return err
 }
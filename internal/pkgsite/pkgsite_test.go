@@ -1,57 +1,280 @@
 package pkgsite_test
 
 import (
-	"strings"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 
-	"github.com/Masterminds/semver/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/selesy/asdf-go-install/internal/config"
 	"github.com/selesy/asdf-go-install/internal/config/configtest"
-	"github.com/selesy/asdf-go-install/internal/gover"
 	"github.com/selesy/asdf-go-install/internal/pkgsite"
+	"github.com/selesy/asdf-go-install/internal/transport"
 )
 
-func TestRepostory(t *testing.T) {
+const (
+	pkg     = "golang.org/x/vuln/cmd/govulncheck"
+	backoff = time.Millisecond
+)
+
+func TestRepository(t *testing.T) {
 	t.Parallel()
 
-	const expRepo = "https://go.googlesource.com/vuln"
+	tests := map[string]struct {
+		handler http.Handler
+		path    string
+	}{
+		"pkg.go.dev": {
+			handler: fixtures(t),
+		},
+		"mirror with path prefix": {
+			handler: http.StripPrefix("/mirror", fixtures(t)),
+			path:    "/mirror",
+		},
+	}
 
-	cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
 
-	repo, err := pkgsite.Repository(cfg, "golang.org/x/vuln/cmd/govulncheck")
-	require.NoError(t, err)
-	assert.Equal(t, expRepo, repo.String())
+			srv := httptest.NewServer(test.handler)
+			t.Cleanup(srv.Close)
+
+			repo, err := pkgsite.Repository(context.Background(), newConfig(t, srv.URL+test.path), pkg)
+			require.NoError(t, err)
+			assert.Equal(t, "https://go.googlesource.com/vuln", repo.String())
+		})
+	}
 }
 
 func TestVersions(t *testing.T) {
 	t.Parallel()
 
-	const (
-		expVersStr = "v0.1.0 v0.2.0 v1.0.0 v1.0.1 v1.0.2 v1.0.3 v1.0.4 v1.1.0 v1.1.1 v1.1.2 v1.1.3"
-	)
+	srv := httptest.NewServer(fixtures(t))
+	t.Cleanup(srv.Close)
 
-	var expVers []*semver.Version
+	vers, err := pkgsite.Versions(context.Background(), newConfig(t, srv.URL), pkg)
+	require.NoError(t, err)
+	assert.Equal(t, "v0.1.0 v0.2.0 v1.0.0 v1.0.1 v1.0.2 v1.0.3 v1.0.4 v1.1.0 v1.1.1 v1.1.2 v1.1.3", vers.String())
+}
 
-	_ = expVers
+func TestRepository_Errors(t *testing.T) {
+	t.Parallel()
 
-	for _, expVerStr := range strings.Split(expVersStr, " ") {
-		expVer, err := gover.NewVersion(expVerStr)
-		require.NoError(t, err)
+	srv := httptest.NewServer(fixtures(t))
+	t.Cleanup(srv.Close)
 
-		expVers = append(expVers, expVer)
+	tests := map[string]struct {
+		pkg    string
+		expErr error
+	}{
+		"fail when package isn't found": {
+			pkg:    "example.com/missing",
+			expErr: pkgsite.ErrPackageNotFound,
+		},
+		"fail when layout has changed": {
+			pkg:    "example.com/changed",
+			expErr: pkgsite.ErrLayoutChanged,
+		},
 	}
 
-	cfg, _, _ := configtest.NewConfig(t, []string{}, []string{})
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
 
-	vers, err := pkgsite.Versions(cfg, "golang.org/x/vuln/cmd/govulncheck")
-	require.NoError(t, err)
+			repo, err := pkgsite.RepositoryWithBackoff(context.Background(), newConfig(t, srv.URL), backoff, test.pkg)
+			require.ErrorIs(t, err, test.expErr)
+			assert.Nil(t, repo)
+		})
+	}
+}
+
+func TestVersions_Errors(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(fixtures(t))
+	t.Cleanup(srv.Close)
+
+	tests := map[string]struct {
+		pkg    string
+		expErr error
+	}{
+		"fail when package isn't found": {
+			pkg:    "example.com/missing",
+			expErr: pkgsite.ErrPackageNotFound,
+		},
+		"fail when layout has changed": {
+			pkg:    "example.com/changed",
+			expErr: pkgsite.ErrLayoutChanged,
+		},
+		"fail when version list has no tags": {
+			pkg:    "example.com/untagged",
+			expErr: pkgsite.ErrLayoutChanged,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			vers, err := pkgsite.VersionsWithBackoff(context.Background(), newConfig(t, srv.URL), backoff, test.pkg)
+			require.ErrorIs(t, err, test.expErr)
+			assert.Nil(t, vers)
+		})
+	}
+}
+
+func TestVersions_Retry(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		status      int
+		retryAfter  string
+		failures    int32
+		expRequests int32
+		expErr      error
+	}{
+		"pass after rate limit with Retry-After seconds": {
+			status:      http.StatusTooManyRequests,
+			retryAfter:  "0",
+			failures:    2,
+			expRequests: 3,
+		},
+		"pass after rate limit with Retry-After date": {
+			status:      http.StatusTooManyRequests,
+			retryAfter:  time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat),
+			failures:    1,
+			expRequests: 2,
+		},
+		"pass after temporary server error with backoff": {
+			status:      http.StatusServiceUnavailable,
+			failures:    pkgsite.RetryAttempts - 1,
+			expRequests: pkgsite.RetryAttempts,
+		},
+		"fail when rate limit persists": {
+			status:      http.StatusTooManyRequests,
+			failures:    pkgsite.RetryAttempts,
+			expRequests: pkgsite.RetryAttempts,
+			expErr:      pkgsite.ErrRateLimited,
+		},
+		"fail when Retry-After is too long": {
+			status:      http.StatusTooManyRequests,
+			retryAfter:  strconv.Itoa(int(time.Hour.Seconds())),
+			failures:    1,
+			expRequests: 1,
+			expErr:      pkgsite.ErrRateLimited,
+		},
+		"fail when server error persists": {
+			status:      http.StatusBadGateway,
+			failures:    pkgsite.RetryAttempts,
+			expRequests: pkgsite.RetryAttempts,
+			expErr:      pkgsite.ErrUnexpectedStatus,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int32
+
+			next := fixtures(t)
 
-	all := vers.All()
-	assert.GreaterOrEqual(t, len(all), len(expVers))
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= test.failures {
+					if test.retryAfter != "" {
+						w.Header().Set("Retry-After", test.retryAfter)
+					}
 
-	for i, expVer := range expVers {
-		assert.Equal(t, expVer, all[i])
+					w.WriteHeader(test.status)
+
+					return
+				}
+
+				next.ServeHTTP(w, r)
+			}))
+			t.Cleanup(srv.Close)
+
+			vers, err := pkgsite.VersionsWithBackoff(context.Background(), newConfig(t, srv.URL), backoff, pkg)
+			require.ErrorIs(t, err, test.expErr)
+			assert.Equal(t, test.expRequests, requests.Load())
+
+			if err != nil {
+				assert.Nil(t, vers)
+
+				return
+			}
+
+			assert.Equal(t, 11, vers.Len())
+		})
 	}
 }
+
+func TestVersions_Canceled(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(fixtures(t))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	vers, err := pkgsite.Versions(ctx, newConfig(t, srv.URL), pkg)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, vers)
+}
+
+func TestVersions_Offline(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"AGI_OFFLINE=true"}, []string{})
+
+	vers, err := pkgsite.Versions(context.Background(), cfg, pkg)
+	require.ErrorIs(t, err, transport.ErrOffline)
+	assert.Nil(t, vers)
+}
+
+func newConfig(t *testing.T, pkgsiteURL string) *config.Config {
+	t.Helper()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"AGI_PKGSITE_URL=" + pkgsiteURL}, []string{})
+
+	return cfg
+}
+
+// fixtures serves the recorded pkg.go.dev pages in testdata.  The page
+// for a package is in index.html and its versions tab is in
+// versions.html.  The search results for a term are in
+// search/<term>.html.
+func fixtures(t *testing.T) http.Handler {
+	t.Helper()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := filepath.Join("testdata", filepath.FromSlash(r.URL.Path), "index.html")
+
+		switch {
+		case r.URL.Path == "/search":
+			name = filepath.Join("testdata", "search", r.URL.Query().Get("q")+".html")
+		case r.URL.Query().Get("tab") == "versions":
+			name = filepath.Join(filepath.Dir(name), "versions.html")
+		}
+
+		data, err := os.ReadFile(name)
+		if err != nil {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(data)
+	})
+}
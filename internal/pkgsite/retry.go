@@ -0,0 +1,100 @@
+package pkgsite
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var _ http.RoundTripper = (*retryTransport)(nil)
+
+// retryTransport retries requests that fail with a 429 (Too Many
+// Requests) or a temporary server error status.  The delay before each
+// retry doubles (starting at backoff and limited to maxBackoff) unless
+// the response includes a Retry-After header.
+//
+// If the response's Retry-After delay is longer than maxBackoff, or if
+// the attempts are exhausted, the last response is returned as-is.
+type retryTransport struct {
+	log        *slog.Logger
+	next       http.RoundTripper
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	delay := t.backoff
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || !retryable(resp.StatusCode) || attempt >= t.attempts {
+			return resp, err
+		}
+
+		wait := delay
+		if after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			wait = after
+		}
+
+		if wait > t.maxBackoff {
+			return resp, nil
+		}
+
+		t.log.Debug(
+			"Retrying request",
+			slog.String("url", req.URL.String()),
+			slog.String("status", resp.Status),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", wait),
+		)
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		delay = min(2*delay, t.maxBackoff)
+	}
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,145 @@
+package pkgsite
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gocolly/colly/v2"
+	"golang.org/x/mod/semver"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+)
+
+const (
+	searchPath        = "search"
+	searchQueryKey    = "q"
+	searchModeKey     = "m"
+	searchModePackage = "package"
+	searchLimitKey    = "limit"
+	searchLimit       = "100"
+
+	searchResultsSelector     = "html body main div.SearchResults"
+	snippetSelector           = "div.SearchSnippet"
+	snippetChipSelector       = "div.SearchSnippet-headerContainer span.go-Chip"
+	snippetLinkSelector       = "div.SearchSnippet-headerContainer h2 a"
+	snippetSynopsisSelector   = "p.SearchSnippet-synopsis"
+	snippetImportedBySelector = "div.SearchSnippet-infoLabel a[href$='tab=importedby'] strong"
+	snippetInfoSelector       = "div.SearchSnippet-infoLabel strong"
+)
+
+// SearchResult describes a command (package main) found by searching
+// pkg.go.dev.
+type SearchResult struct {
+	// Path is the command's import path.
+	Path string
+
+	// Synopsis is the first sentence of the command's documentation.
+	Synopsis string
+
+	// Version is the latest version of the command's module.
+	Version string
+
+	// ImportedBy is the number of packages that import the command.
+	ImportedBy int
+}
+
+// WriteSearchResults writes the results as the aligned, human-readable
+// text shown by the search extension command.  Each command's import
+// path is the first column so that it can be passed to the add
+// extension command.
+func WriteSearchResults(w io.Writer, results []*SearchResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "PATH\tVERSION\tIMPORTED BY\tSYNOPSIS")
+
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%d", r.Path, r.Version, r.ImportedBy)
+
+		if r.Synopsis != "" {
+			fmt.Fprintf(tw, "\t%s", r.Synopsis)
+		}
+
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+// Search scrapes the first page of package search results for the
+// provided term from the pkg.go.dev web-site (or the pkgsite mirror
+// configured by AGI_PKGSITE_URL) and returns the results that are
+// commands.
+//
+// Requests are retried and errors are reported as described for
+// Repository, except that ErrLayoutChanged is returned if the page
+// doesn't contain a list of search results.
+func Search(ctx context.Context, cfg *config.Config, term string) ([]*SearchResult, error) {
+	return search(ctx, cfg, defaultOptions, term)
+}
+
+func search(ctx context.Context, cfg *config.Config, opts options, term string) ([]*SearchResult, error) {
+	u := cfg.Env().PkgsiteURL().JoinPath(searchPath)
+	u.RawQuery = url.Values{
+		searchQueryKey: {term},
+		searchModeKey:  {searchModePackage},
+		searchLimitKey: {searchLimit},
+	}.Encode()
+
+	cfg.Log().Debug(
+		"Scraping target",
+		slog.String("url", u.String()),
+		slog.String("goal", "search"),
+	)
+
+	var (
+		results []*SearchResult
+		found   bool
+	)
+
+	err := scrape(ctx, cfg, opts, u, func(col *colly.Collector) {
+		col.OnHTML(searchResultsSelector, func(h *colly.HTMLElement) {
+			found = true
+
+			h.ForEach(snippetSelector, func(_ int, h *colly.HTMLElement) {
+				if !isCommand(h, snippetChipSelector) {
+					return
+				}
+
+				results = append(results, &SearchResult{
+					Path:       strings.Trim(h.ChildAttr(snippetLinkSelector, "href"), "/"),
+					Synopsis:   strings.Join(strings.Fields(h.ChildText(snippetSynopsisSelector)), " "),
+					Version:    snippetVersion(h),
+					ImportedBy: parseCount(h.ChildText(snippetImportedBySelector)),
+				})
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: no search results in %s", ErrLayoutChanged, u)
+	}
+
+	return results, nil
+}
+
+// snippetVersion finds the version in the search result's details since
+// the elements that contain it aren't otherwise labeled.
+func snippetVersion(h *colly.HTMLElement) string {
+	var ver string
+
+	h.ForEach(snippetInfoSelector, func(_ int, h *colly.HTMLElement) {
+		if text := strings.TrimSpace(h.Text); ver == "" && semver.IsValid(text) {
+			ver = text
+		}
+	})
+
+	return ver
+}
@@ -0,0 +1,94 @@
+package pkgsite_test
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gotest.tools/v3/golden"
+
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/pkgsite"
+	"github.com/selesy/asdf-go-install/internal/transport"
+)
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(fixtures(t))
+	t.Cleanup(srv.Close)
+
+	tests := map[string]struct {
+		term   string
+		exp    []*pkgsite.SearchResult
+		expErr error
+	}{
+		"pass with command results": {
+			term: "gofumpt",
+			exp: []*pkgsite.SearchResult{
+				{
+					Path:       "mvdan.cc/gofumpt",
+					Synopsis:   "Gofumpt enforces a stricter format than gofmt, while being backwards compatible.",
+					Version:    "v0.7.0",
+					ImportedBy: 3,
+				},
+				{
+					Path:    "github.com/example/gofumpt-wrapper/cmd/gofumptw",
+					Version: "v0.0.0-20240102030405-abcdefabcdef",
+				},
+			},
+		},
+		"pass without results": {
+			term: "nothing",
+		},
+		"fail when layout has changed": {
+			term:   "changed",
+			expErr: pkgsite.ErrLayoutChanged,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			results, err := pkgsite.Search(context.Background(), newConfig(t, srv.URL), test.term)
+			require.ErrorIs(t, err, test.expErr)
+			assert.Equal(t, test.exp, results)
+		})
+	}
+}
+
+func TestSearch_Offline(t *testing.T) {
+	t.Parallel()
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"AGI_OFFLINE=true"}, []string{})
+
+	results, err := pkgsite.Search(context.Background(), cfg, "gofumpt")
+	require.ErrorIs(t, err, transport.ErrOffline)
+	assert.Nil(t, results)
+}
+
+func TestWriteSearchResults(t *testing.T) {
+	t.Parallel()
+
+	results := []*pkgsite.SearchResult{
+		{
+			Path:       "mvdan.cc/gofumpt",
+			Synopsis:   "Gofumpt enforces a stricter format than gofmt, while being backwards compatible.",
+			Version:    "v0.7.0",
+			ImportedBy: 3,
+		},
+		{
+			Path:    "github.com/example/gofumpt-wrapper/cmd/gofumptw",
+			Version: "v0.0.0-20240102030405-abcdefabcdef",
+		},
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, pkgsite.WriteSearchResults(&buf, results))
+	golden.Assert(t, buf.String(), "search.txt")
+}
@@ -0,0 +1,20 @@
+package sumdb
+
+import "errors"
+
+// ErrChecksumMismatch is returned when the hash of a downloaded module
+// zip doesn't match the hash published by the checksum database or the
+// hash that was previously verified.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrInvalidSumDB is returned when the value of GOSUMDB can't be parsed
+// or names a checksum database whose public key isn't known.
+var ErrInvalidSumDB = errors.New("invalid checksum database")
+
+// ErrNotFound is returned when the checksum database responds with a
+// 404 (Not Found) or 410 (Gone) status.
+var ErrNotFound = errors.New("not found in checksum database")
+
+// ErrUnexpectedStatus is returned when the checksum database responds
+// with a status other than 200 (OK), 404 (Not Found) or 410 (Gone).
+var ErrUnexpectedStatus = errors.New("unexpected response from checksum database")
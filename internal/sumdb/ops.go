@@ -0,0 +1,181 @@
+package sumdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/lmittmann/tint"
+	gosumdb "golang.org/x/mod/sumdb"
+
+	"github.com/selesy/asdf-go-install/internal/lockedfile"
+)
+
+const keyConfig = "key"
+
+var _ gosumdb.ClientOps = (*clientOps)(nil)
+
+// clientOps provides the network and storage operations used by the
+// checksum database client for a single lookup.  The files named by the
+// client (e.g. sum.golang.org/latest) are stored in the Verifier's
+// CacheDirname.
+type clientOps struct {
+	ctx context.Context
+	v   *Verifier
+
+	// err is the last error returned by ReadRemote and security is the
+	// last message passed to SecurityError since the client only
+	// includes their text in the errors it returns.  The client calls
+	// both concurrently.
+	mu       sync.Mutex
+	err      error
+	security string
+}
+
+// ReadRemote implements gosumdb.ClientOps.
+func (o *clientOps) ReadRemote(path string) ([]byte, error) {
+	data, err := o.readRemote(path)
+	if err != nil {
+		o.mu.Lock()
+		o.err = err
+		o.mu.Unlock()
+	}
+
+	return data, err
+}
+
+func (o *clientOps) readRemote(path string) ([]byte, error) {
+	u := o.v.url.JoinPath(path)
+
+	o.v.cfg.Log().Debug(
+		"Fetching from checksum database",
+		slog.String("url", u.String()),
+	)
+
+	req, err := http.NewRequestWithContext(o.ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.v.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound, http.StatusGone:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, u)
+	default:
+		return nil, fmt.Errorf("%w: %s: %s", ErrUnexpectedStatus, u, resp.Status)
+	}
+}
+
+// ReadConfig implements gosumdb.ClientOps.  The key is taken from
+// GOSUMDB and a missing signed tree head is reported as empty so that
+// the client trusts the first one it downloads.
+func (o *clientOps) ReadConfig(file string) ([]byte, error) {
+	if file == keyConfig {
+		return []byte(o.v.key), nil
+	}
+
+	data, err := lockedfile.Read(o.path(file))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []byte{}, nil
+	}
+
+	return data, err
+}
+
+// WriteConfig implements gosumdb.ClientOps.
+func (o *clientOps) WriteConfig(file string, oldData []byte, newData []byte) error {
+	if file == keyConfig {
+		return fmt.Errorf("%w: can't write %s", ErrInvalidSumDB, file)
+	}
+
+	path := o.path(file)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return lockedfile.Update(path, 0o644, func(data []byte) ([]byte, error) {
+		if !bytes.Equal(data, oldData) {
+			return nil, gosumdb.ErrWriteConflict
+		}
+
+		return newData, nil
+	})
+}
+
+// ReadCache implements gosumdb.ClientOps.
+func (o *clientOps) ReadCache(file string) ([]byte, error) {
+	return os.ReadFile(o.path(filepath.Join("cache", file)))
+}
+
+// WriteCache implements gosumdb.ClientOps.  The cached files never
+// change once they're written, so they're only renamed into place
+// instead of being locked.  Failures are logged since the data can be
+// downloaded again.
+func (o *clientOps) WriteCache(file string, data []byte) {
+	if err := writeCache(o.path(filepath.Join("cache", file)), data); err != nil {
+		o.v.cfg.Log().Warn("Failed to cache checksum database data", slog.String("file", file), tint.Err(err))
+	}
+}
+
+// Log implements gosumdb.ClientOps.
+func (o *clientOps) Log(msg string) {
+	o.v.cfg.Log().Debug(msg)
+}
+
+// SecurityError implements gosumdb.ClientOps.
+func (o *clientOps) SecurityError(msg string) {
+	o.v.cfg.Log().Error("Checksum database security error", slog.String("message", msg))
+
+	o.mu.Lock()
+	o.security = msg
+	o.mu.Unlock()
+}
+
+// path returns the location of the client's file in the Verifier's
+// CacheDirname.
+func (o *clientOps) path(file string) string {
+	return filepath.Join(o.v.dir, CacheDirname, filepath.FromSlash(file))
+}
+
+func writeCache(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+
+	return err
+}
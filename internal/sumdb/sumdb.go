@@ -0,0 +1,351 @@
+// Package sumdb verifies downloaded module zips against a Go checksum
+// database using the same GOSUMDB, GONOSUMDB and GOPRIVATE settings as
+// the go command.  See [Checksum database] for a description of the
+// protocol and its signed tree heads and tiles.
+//
+// Verified hashes are recorded in go.sum format in the plugin's
+// directory (next to the plugin's manifest) so that a version is only
+// looked up once and so that a module that's later republished with
+// different contents is detected.  The checksum database's latest
+// signed tree head and the tiles used to prove each lookup are cached
+// in the same directory.
+//
+// [Checksum database]: https://go.dev/ref/mod#checksum-database
+package sumdb
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/module"
+	gosumdb "golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/mod/sumdb/note"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/lockedfile"
+	"github.com/selesy/asdf-go-install/internal/modcache"
+	"github.com/selesy/asdf-go-install/internal/transport"
+)
+
+const (
+	// VerifiedFilename is the name of the file in the plugin's directory
+	// that records the verified module hashes in go.sum format.
+	VerifiedFilename = "verified.sum"
+
+	// CacheDirname is the name of the directory in the plugin's
+	// directory that stores the checksum database's latest signed tree
+	// head and the tiles and records that were downloaded.
+	CacheDirname = "sumdb"
+
+	// DefaultName is the name of the checksum database used when
+	// GOSUMDB is unset.
+	DefaultName = "sum.golang.org"
+
+	// DefaultKey is the public key of the DefaultName checksum database.
+	DefaultKey = "sum.golang.org+033de0ae+Ac4zctda0e5eza+HJyk9SxEdh+s3Ux18htTTAD8OuAn8"
+
+	sumDBOff    = "off"
+	goModSuffix = "/go.mod"
+)
+
+// Verifier checks module zips against the checksum database configured
+// by GOSUMDB.
+type Verifier struct {
+	cfg  *config.Config
+	dir  string
+	off  bool
+	key  string
+	url  *url.URL
+	http *http.Client
+}
+
+// New creates a Verifier that records verified hashes and caches the
+// checksum database's data in the provided plugin directory.
+//
+// GOSUMDB is parsed as by the go command: "off", the name of a known
+// checksum database (only DefaultName,) or the database's public key
+// optionally followed by its URL.  The URL defaults to https://<name>.
+// ErrInvalidSumDB is returned if the value can't be parsed.
+func New(cfg *config.Config, dir string) (*Verifier, error) {
+	v := &Verifier{
+		cfg: cfg,
+		dir: dir,
+		http: &http.Client{
+			Transport: transport.Offline(cfg, http.DefaultTransport),
+			Timeout:   cfg.Env().RequestTimeout(),
+		},
+	}
+
+	fields := strings.Fields(cfg.Env().GoSumDB())
+
+	switch {
+	case len(fields) == 0:
+		fields = []string{DefaultName}
+	case len(fields) == 1 && fields[0] == sumDBOff:
+		v.off = true
+
+		return v, nil
+	case len(fields) > 2:
+		return nil, fmt.Errorf("%w: GOSUMDB=%q", ErrInvalidSumDB, cfg.Env().GoSumDB())
+	}
+
+	v.key = fields[0]
+	if v.key == DefaultName {
+		v.key = DefaultKey
+	}
+
+	verifier, err := note.NewVerifier(v.key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: GOSUMDB=%q: %w", ErrInvalidSumDB, cfg.Env().GoSumDB(), err)
+	}
+
+	rawURL := "https://" + verifier.Name()
+	if len(fields) == 2 {
+		rawURL = fields[1]
+	}
+
+	v.url, err = url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: GOSUMDB=%q: %w", ErrInvalidSumDB, cfg.Env().GoSumDB(), err)
+	}
+
+	if v.url.Scheme != "http" && v.url.Scheme != "https" || v.url.Host == "" {
+		return nil, fmt.Errorf("%w: GOSUMDB=%q: URL must be absolute", ErrInvalidSumDB, cfg.Env().GoSumDB())
+	}
+
+	return v, nil
+}
+
+// Verify checks the h1: hash of the module zip (see HashZip) against the
+// hash recorded by a previous verification or, for versions that
+// haven't been verified, against the go.sum lines published by the
+// checksum database.  ErrChecksumMismatch is returned if the hashes
+// differ.  Newly verified hashes are recorded.
+//
+// Verification is skipped when GONOSUMCHECK is set, when GOSUMDB is off
+// or when the module matches GONOSUMDB (or GOPRIVATE.)  Recorded hashes
+// and cached checksum database data are used without network access.
+// In offline mode (AGI_OFFLINE,) a version that hasn't been verified is
+// checked against the hash that the go command recorded when it added
+// the zip to the Go module cache (see modcache.ZipHash) instead.
+func (v *Verifier) Verify(ctx context.Context, mod string, ver string, zipData []byte) error {
+	if reason := v.skip(mod); reason != "" {
+		v.cfg.Log().Debug(
+			"Skipping checksum verification",
+			slog.String("module", mod),
+			slog.String("version", ver),
+			slog.String("reason", reason),
+		)
+
+		return nil
+	}
+
+	hash, err := HashZip(zipData)
+	if err != nil {
+		return fmt.Errorf("%s@%s: %w", mod, ver, err)
+	}
+
+	recorded, err := v.recorded(mod, ver)
+	if err != nil {
+		return err
+	}
+
+	if recorded != "" {
+		return compare(mod, ver, hash, recorded, VerifiedFilename)
+	}
+
+	if v.cfg.Env().Offline() {
+		return v.verifyCached(mod, ver, hash)
+	}
+
+	lines, err := v.lookup(ctx, mod, ver)
+	if err != nil {
+		return err
+	}
+
+	published := ""
+
+	for _, line := range lines {
+		if fields := strings.Fields(line); len(fields) == 3 && fields[1] == ver {
+			published = fields[2]
+		}
+	}
+
+	if published == "" {
+		return fmt.Errorf("%w: %s@%s", ErrNotFound, mod, ver)
+	}
+
+	if err := compare(mod, ver, hash, published, v.url.Host); err != nil {
+		return err
+	}
+
+	v.cfg.Log().Debug(
+		"Verified module checksum",
+		slog.String("module", mod),
+		slog.String("version", ver),
+		slog.String("hash", hash),
+	)
+
+	return v.record(lines)
+}
+
+// HashZip returns the h1: hash of the module zip's files as computed by
+// the go command and published by the checksum database.
+func HashZip(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	files := make([]string, 0, len(zr.File))
+	entries := make(map[string]*zip.File, len(zr.File))
+
+	for _, f := range zr.File {
+		files = append(files, f.Name)
+		entries[f.Name] = f
+	}
+
+	return dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		return entries[name].Open()
+	})
+}
+
+// verifyCached checks the hash of the module zip against the hash that
+// the go command stored next to it in the Go module cache.  The hash
+// isn't recorded since it wasn't verified by the checksum database.
+func (v *Verifier) verifyCached(mod string, ver string, hash string) error {
+	cached, err := modcache.ZipHash(v.cfg, mod, ver)
+	if errors.Is(err, modcache.ErrNotCached) || errors.Is(err, modcache.ErrNoModCache) {
+		return fmt.Errorf("%w: %s@%s hasn't been verified: %w", transport.ErrOffline, mod, ver, err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	v.cfg.Log().Debug(
+		"Verifying module checksum against the Go module cache",
+		slog.String("module", mod),
+		slog.String("version", ver),
+		slog.String("hash", cached),
+	)
+
+	return compare(mod, ver, hash, cached, "the Go module cache")
+}
+
+// skip returns the reason why the module shouldn't be verified or an
+// empty string if it should.
+func (v *Verifier) skip(mod string) string {
+	switch {
+	case v.cfg.Env().GoNoSumCheck():
+		return "GONOSUMCHECK is set"
+	case v.off:
+		return "GOSUMDB is off"
+	case module.MatchPrefixPatterns(v.cfg.Env().GoNoSumDB(), mod):
+		return "module matches GONOSUMDB"
+	default:
+		return ""
+	}
+}
+
+// lookup returns the go.sum lines published by the checksum database for
+// the module version and its go.mod file after verifying them against
+// the database's signed tree head.
+func (v *Verifier) lookup(ctx context.Context, mod string, ver string) ([]string, error) {
+	ops := &clientOps{
+		ctx: ctx,
+		v:   v,
+	}
+
+	client := gosumdb.NewClient(ops)
+
+	// The go.mod hash is recorded too so that the verified hashes can
+	// be used as a go.sum file.  The client reuses the first lookup's
+	// record to find it.
+	lines, err := client.Lookup(mod, ver)
+	if err == nil {
+		var modLines []string
+
+		modLines, err = client.Lookup(mod, ver+goModSuffix)
+		lines = append(lines, modLines...)
+	}
+
+	switch {
+	case err == nil:
+		return lines, nil
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	}
+
+	ops.mu.Lock()
+	defer ops.mu.Unlock()
+
+	switch {
+	case ops.security != "":
+		return nil, fmt.Errorf("%s@%s: %w: %s", mod, ver, gosumdb.ErrSecurity, ops.security)
+	case ops.err != nil:
+		// The client doesn't wrap the errors returned by ReadRemote.
+		return nil, fmt.Errorf("%s@%s: %w", mod, ver, ops.err)
+	default:
+		return nil, err
+	}
+}
+
+// recorded returns the hash recorded for the module version or an empty
+// string if the version hasn't been verified.
+func (v *Verifier) recorded(mod string, ver string) (string, error) {
+	data, err := lockedfile.Read(filepath.Join(v.dir, VerifiedFilename))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) == 3 && fields[0] == mod && fields[1] == ver {
+			return fields[2], scanner.Err()
+		}
+	}
+
+	return "", scanner.Err()
+}
+
+// record adds the go.sum lines to the verified hashes, keeping the file
+// sorted like a go.sum file.
+func (v *Verifier) record(lines []string) error {
+	return lockedfile.Update(filepath.Join(v.dir, VerifiedFilename), 0o644, func(data []byte) ([]byte, error) {
+		all := slices.DeleteFunc(strings.Split(string(data), "\n"), func(line string) bool {
+			return strings.TrimSpace(line) == ""
+		})
+
+		all = append(all, lines...)
+		slices.Sort(all)
+		all = slices.Compact(all)
+
+		return []byte(strings.Join(all, "\n") + "\n"), nil
+	})
+}
+
+func compare(mod string, ver string, hash string, exp string, source string) error {
+	if hash == exp {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s@%s: downloaded %s but %s has %s", ErrChecksumMismatch, mod, ver, hash, source, exp)
+}
@@ -0,0 +1,332 @@
+package sumdb_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gosumdb "golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/mod/sumdb/note"
+
+	"github.com/selesy/asdf-go-install/internal/config"
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/sumdb"
+	"github.com/selesy/asdf-go-install/internal/transport"
+)
+
+const (
+	mod = "example.com/tool"
+	ver = "v1.1.0"
+)
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	published := makeZip(t, mod, ver, "package main\n")
+	tampered := makeZip(t, mod, ver, "package main // tampered\n")
+
+	tests := map[string]struct {
+		zip      []byte
+		environ  []string
+		recorded string
+		ziphash  string
+		expErr   error
+	}{
+		"pass with published hash": {
+			zip: published,
+		},
+		"pass with recorded hash": {
+			zip:      published,
+			recorded: fmt.Sprintf("%s %s %s\n", mod, ver, hashZip(t, published)),
+		},
+		"pass when GONOSUMCHECK is set": {
+			zip:     tampered,
+			environ: []string{"GONOSUMCHECK=1"},
+		},
+		"pass when GOSUMDB is off": {
+			zip:     tampered,
+			environ: []string{"GOSUMDB=off"},
+		},
+		"pass when module matches GONOSUMDB": {
+			zip:     tampered,
+			environ: []string{"GONOSUMDB=example.com"},
+		},
+		"pass when module matches GOPRIVATE": {
+			zip:     tampered,
+			environ: []string{"GOPRIVATE=example.com/*"},
+		},
+		"fail with tampered zip": {
+			zip:    tampered,
+			expErr: sumdb.ErrChecksumMismatch,
+		},
+		"fail with different recorded hash": {
+			zip:      published,
+			recorded: fmt.Sprintf("%s %s %s\n", mod, ver, hashZip(t, tampered)),
+			expErr:   sumdb.ErrChecksumMismatch,
+		},
+		"pass offline with the Go module cache's hash": {
+			zip:     published,
+			environ: []string{"AGI_OFFLINE=true"},
+			ziphash: hashZip(t, published),
+		},
+		"fail offline with different Go module cache hash": {
+			zip:     tampered,
+			environ: []string{"AGI_OFFLINE=true"},
+			ziphash: hashZip(t, published),
+			expErr:  sumdb.ErrChecksumMismatch,
+		},
+		"fail offline without recorded hash": {
+			zip:     published,
+			environ: []string{"AGI_OFFLINE=true"},
+			expErr:  transport.ErrOffline,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv, lookups := newServer(t, map[string][]byte{mod + "@" + ver: published})
+			dir := t.TempDir()
+
+			if test.recorded != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, sumdb.VerifiedFilename), []byte(test.recorded), 0o644))
+			}
+
+			modCache := t.TempDir()
+
+			if test.ziphash != "" {
+				download := filepath.Join(modCache, "cache", "download", filepath.FromSlash(mod), "@v")
+				require.NoError(t, os.MkdirAll(download, 0o755))
+				require.NoError(t, os.WriteFile(filepath.Join(download, ver+".ziphash"), []byte(test.ziphash), 0o644))
+			}
+
+			v, err := sumdb.New(newConfig(t, srv, append(test.environ, "GOMODCACHE="+modCache)...), dir)
+			require.NoError(t, err)
+
+			err = v.Verify(context.Background(), mod, ver, test.zip)
+			require.ErrorIs(t, err, test.expErr)
+
+			if test.recorded != "" || test.ziphash != "" {
+				assert.Zero(t, lookups.Load())
+			}
+
+			if test.ziphash != "" {
+				assert.NoFileExists(t, filepath.Join(dir, sumdb.VerifiedFilename))
+			}
+		})
+	}
+}
+
+func TestVerify_Records(t *testing.T) {
+	t.Parallel()
+
+	data := makeZip(t, mod, ver, "package main\n")
+	srv, lookups := newServer(t, map[string][]byte{mod + "@" + ver: data})
+	dir := t.TempDir()
+
+	v, err := sumdb.New(newConfig(t, srv), dir)
+	require.NoError(t, err)
+	require.NoError(t, v.Verify(context.Background(), mod, ver, data))
+	assert.Equal(t, int32(1), lookups.Load())
+
+	recorded, err := os.ReadFile(filepath.Join(dir, sumdb.VerifiedFilename))
+	require.NoError(t, err)
+	assert.Equal(t, goSum(t, mod, ver, data), string(recorded))
+	assert.FileExists(t, filepath.Join(dir, sumdb.CacheDirname, srv.name, "latest"))
+
+	// The recorded hash is used offline and without another lookup.
+	offline, err := sumdb.New(newConfig(t, srv, "AGI_OFFLINE=true"), dir)
+	require.NoError(t, err)
+	require.NoError(t, offline.Verify(context.Background(), mod, ver, data))
+	assert.Equal(t, int32(1), lookups.Load())
+}
+
+func TestVerify_NotFound(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newServer(t, map[string][]byte{})
+
+	v, err := sumdb.New(newConfig(t, srv), t.TempDir())
+	require.NoError(t, err)
+
+	err = v.Verify(context.Background(), mod, ver, makeZip(t, mod, ver, "package main\n"))
+	require.ErrorIs(t, err, sumdb.ErrNotFound)
+}
+
+func TestVerify_WrongKey(t *testing.T) {
+	t.Parallel()
+
+	data := makeZip(t, mod, ver, "package main\n")
+	srv, _ := newServer(t, map[string][]byte{mod + "@" + ver: data})
+
+	_, vkey, err := note.GenerateKey(rand.Reader, srv.name)
+	require.NoError(t, err)
+
+	cfg, _, _ := configtest.NewConfig(t, []string{"GOSUMDB=" + vkey + " " + srv.URL}, []string{})
+
+	v, err := sumdb.New(cfg, t.TempDir())
+	require.NoError(t, err)
+	require.Error(t, v.Verify(context.Background(), mod, ver, data))
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		gosumdb string
+		expErr  error
+	}{
+		"pass with default":             {gosumdb: "sum.golang.org"},
+		"pass with default key and URL": {gosumdb: sumdb.DefaultKey + " https://sum.example.com/sumdb"},
+		"pass when off":                 {gosumdb: "off"},
+		"fail with unknown name":        {gosumdb: "sum.example.com", expErr: sumdb.ErrInvalidSumDB},
+		"fail with relative URL":        {gosumdb: sumdb.DefaultKey + " sum.example.com", expErr: sumdb.ErrInvalidSumDB},
+		"fail with extra fields":        {gosumdb: sumdb.DefaultKey + " https://sum.example.com extra", expErr: sumdb.ErrInvalidSumDB},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, _ := configtest.NewConfig(t, []string{"GOSUMDB=" + test.gosumdb}, []string{})
+
+			v, err := sumdb.New(cfg, t.TempDir())
+			require.ErrorIs(t, err, test.expErr)
+
+			if test.expErr != nil {
+				assert.Nil(t, v)
+			}
+		})
+	}
+}
+
+func TestHashZip(t *testing.T) {
+	t.Parallel()
+
+	data := makeZip(t, mod, ver, "package main\n")
+
+	hash, err := sumdb.HashZip(data)
+	require.NoError(t, err)
+	assert.Equal(t, hashZip(t, data), hash)
+
+	_, err = sumdb.HashZip([]byte("not a zip"))
+	require.Error(t, err)
+}
+
+// server is a checksum database that serves the go.sum lines for its
+// module zips and signs its tree heads with a key generated for the
+// test.
+type server struct {
+	*httptest.Server
+
+	name string
+	vkey string
+}
+
+// newServer starts a server for the module zips (keyed by module@version)
+// and returns it along with the number of lookups it has served.
+func newServer(t *testing.T, zips map[string][]byte) (*server, *atomic.Int32) {
+	t.Helper()
+
+	const name = "sum.example.com"
+
+	skey, vkey, err := note.GenerateKey(rand.Reader, name)
+	require.NoError(t, err)
+
+	ts := gosumdb.NewTestServer(skey, func(path string, vers string) ([]byte, error) {
+		data, ok := zips[path+"@"+vers]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+
+		return []byte(goSum(t, path, vers, data)), nil
+	})
+
+	var (
+		handler = gosumdb.NewServer(ts)
+		lookups atomic.Int32
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/lookup/") {
+			lookups.Add(1)
+		}
+
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	return &server{Server: srv, name: name, vkey: vkey}, &lookups
+}
+
+func newConfig(t *testing.T, srv *server, environ ...string) *config.Config {
+	t.Helper()
+
+	cfg, _, _ := configtest.NewConfig(t, append([]string{"GOSUMDB=" + srv.vkey + " " + srv.URL}, environ...), []string{})
+
+	return cfg
+}
+
+// goSum returns the go.sum lines for the module zip and its go.mod file.
+func goSum(t *testing.T, path string, vers string, data []byte) string {
+	t.Helper()
+
+	modHash, err := dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("module " + path + "\n")), nil
+	})
+	require.NoError(t, err)
+
+	return fmt.Sprintf("%s %s %s\n%s %s/go.mod %s\n", path, vers, hashZip(t, data), path, vers, modHash)
+}
+
+// hashZip hashes the module zip with dirhash.HashZip to check HashZip
+// against the go command's implementation.
+func hashZip(t *testing.T, data []byte) string {
+	t.Helper()
+
+	name := filepath.Join(t.TempDir(), "module.zip")
+	require.NoError(t, os.WriteFile(name, data, 0o644))
+
+	hash, err := dirhash.HashZip(name, dirhash.Hash1)
+	require.NoError(t, err)
+
+	return hash
+}
+
+func makeZip(t *testing.T, path string, vers string, src string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	for name, content := range map[string]string{
+		"go.mod":  "module " + path + "\n",
+		"main.go": src,
+	} {
+		f, err := zw.Create(path + "@" + vers + "/" + name)
+		require.NoError(t, err)
+
+		_, err = io.WriteString(f, content)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+
+	return buf.Bytes()
+}
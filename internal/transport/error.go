@@ -0,0 +1,7 @@
+package transport
+
+import "errors"
+
+// ErrOffline is returned for network requests that are made while the
+// plugin is running in offline mode (AGI_OFFLINE.)
+var ErrOffline = errors.New("network access is disabled by AGI_OFFLINE")
@@ -0,0 +1,65 @@
+// Package transport provides the http.RoundTripper used for every
+// network request made by the plugin, so that offline mode (AGI_OFFLINE)
+// is enforced in a single place instead of by each caller.
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/selesy/asdf-go-install/internal/cache"
+	"github.com/selesy/asdf-go-install/internal/config"
+)
+
+// New returns the http.RoundTripper used for the plugin's requests:
+// responses are cached while collecting versions (see cache.Transport)
+// and requests are refused in offline mode (see Offline.)
+func New(ctx context.Context, cfg *config.Config) http.RoundTripper {
+	return Offline(cfg, cache.Transport(ctx, http.DefaultTransport))
+}
+
+// Offline returns an http.RoundTripper that fails every request with
+// ErrOffline in offline mode and passes them to next otherwise.
+func Offline(cfg *config.Config, next http.RoundTripper) http.RoundTripper {
+	if !cfg.Env().Offline() {
+		return next
+	}
+
+	return offlineTransport{}
+}
+
+// WithContext returns an http.RoundTripper that attaches the context to
+// each request before passing it to next, since colly doesn't provide a
+// way to do so.
+func WithContext(ctx context.Context, next http.RoundTripper) http.RoundTripper {
+	return &contextTransport{
+		ctx:  ctx,
+		next: next,
+	}
+}
+
+var _ http.RoundTripper = offlineTransport{}
+
+type offlineTransport struct{}
+
+// RoundTrip implements http.RoundTripper.
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrOffline, req.Method, req.URL)
+}
+
+var _ http.RoundTripper = (*contextTransport)(nil)
+
+type contextTransport struct {
+	ctx  context.Context
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.next.RoundTrip(req.WithContext(t.ctx))
+}
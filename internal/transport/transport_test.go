@@ -0,0 +1,77 @@
+package transport_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/selesy/asdf-go-install/internal/config/configtest"
+	"github.com/selesy/asdf-go-install/internal/transport"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		environ     []string
+		expErr      error
+		expRequests int32
+	}{
+		"online": {
+			environ:     []string{},
+			expRequests: 1,
+		},
+		"offline": {
+			environ: []string{"AGI_OFFLINE=true"},
+			expErr:  transport.ErrOffline,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				requests.Add(1)
+			}))
+			t.Cleanup(srv.Close)
+
+			cfg, _, _ := configtest.NewConfig(t, test.environ, []string{})
+			client := &http.Client{Transport: transport.New(context.Background(), cfg)}
+
+			resp, err := client.Get(srv.URL)
+			require.ErrorIs(t, err, test.expErr)
+
+			if err == nil {
+				resp.Body.Close()
+			}
+
+			assert.Equal(t, test.expRequests, requests.Load())
+		})
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("unexpected request with a canceled context")
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := &http.Client{Transport: transport.WithContext(ctx, http.DefaultTransport)}
+
+	resp, err := client.Get(srv.URL)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, resp)
+}
@@ -0,0 +1 @@
+../../bin/asdf-go-install
\ No newline at end of file
@@ -1,11 +1,21 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/selesy/asdf-go-install/internal/agi"
 )
 
 func main() {
-	os.Exit(agi.Main())
+	// Interrupting asdf (e.g. Ctrl-C during list-all) cancels in-flight
+	// requests and builds instead of killing the process mid-write.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	code := agi.Main(ctx)
+
+	stop()
+	os.Exit(code)
 }